@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// botButtons - сколько действий предлагается кнопками под ответом
+	botButtons = 12
+	// botButtonData - сколько байт помещается в данные кнопки
+	botButtonData   = 64
+	botRetryDelay   = 3 * time.Second
+	tooManyCommands = "слишком много команд, подождите немного"
+)
+
+// Bot - чат-бот для мессенджера с Bot API по образцу Telegram: забирает
+// сообщения длинным опросом getUpdates и отвечает sendMessage. У каждого чата
+// своя отдельная игра, а под ответом - кнопки с доступными действиями.
+// Команда /start начинает игру заново
+type Bot struct {
+	World *World
+	// API - адрес Bot API вместе с токеном: https://api.telegram.org/bot<токен>
+	API    string
+	Client *http.Client
+	// PollTimeout - сколько мессенджер держит запрос, дожидаясь новых сообщений
+	PollTimeout time.Duration
+	// SessionTTL - через сколько бездействия игра чата забывается, 0 - никогда
+	SessionTTL time.Duration
+	// RateLimit - сколько команд чат может прислать за RateWindow, 0 - без ограничений
+	RateLimit  int
+	RateWindow time.Duration
+	// Locale - язык новых игр, пустой - язык по умолчанию
+	Locale string
+
+	offset int64
+	chats  map[int64]*chatSession
+	// now - текущее время, в тестах его подменяют
+	now func() time.Time
+}
+
+// chatSession - игра одного чата
+type chatSession struct {
+	game     *Game
+	lastSeen time.Time
+	// recent - когда пришли последние команды, для ограничения частоты
+	recent []time.Time
+	// warned - о превышении частоты в этом окне уже предупредили
+	warned bool
+}
+
+type botUpdate struct {
+	UpdateID      int64        `json:"update_id"`
+	Message       *botMessage  `json:"message,omitempty"`
+	CallbackQuery *botCallback `json:"callback_query,omitempty"`
+}
+
+type botMessage struct {
+	Chat botChat `json:"chat"`
+	Text string  `json:"text"`
+}
+
+type botChat struct {
+	ID int64 `json:"id"`
+}
+
+// botCallback - нажатие кнопки, в Data - команда
+type botCallback struct {
+	ID      string      `json:"id"`
+	Data    string      `json:"data"`
+	Message *botMessage `json:"message,omitempty"`
+}
+
+type botResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description,omitempty"`
+}
+
+type getUpdatesRequest struct {
+	Offset  int64 `json:"offset"`
+	Timeout int   `json:"timeout"`
+}
+
+type sendMessageRequest struct {
+	ChatID      int64           `json:"chat_id"`
+	Text        string          `json:"text"`
+	ReplyMarkup *inlineKeyboard `json:"reply_markup,omitempty"`
+}
+
+type inlineKeyboard struct {
+	InlineKeyboard [][]inlineButton `json:"inline_keyboard"`
+}
+
+type inlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type answerCallbackRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+}
+
+// Run опрашивает мессенджер, пока не отменят ctx. Ошибки связи не прерывают
+// работу: бот ждет немного и пробует снова
+func (b *Bot) Run(ctx context.Context) error {
+	for {
+		if err := b.Poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			select {
+			case <-time.After(botRetryDelay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Poll забирает одну пачку сообщений, забывает игры, простоявшие дольше
+// SessionTTL, и отвечает на сообщения по порядку. Возвращает первую ошибку связи
+func (b *Bot) Poll(ctx context.Context) error {
+	var updates []botUpdate
+	req := getUpdatesRequest{Offset: b.offset, Timeout: int(b.PollTimeout / time.Second)}
+	if err := b.call(ctx, "getUpdates", req, &updates); err != nil {
+		return err
+	}
+	b.expire()
+	var firstErr error
+	for _, u := range updates {
+		// сообщение считается обработанным, даже если ответ не ушел, - иначе
+		// одна и та же команда выполнялась бы снова и снова
+		b.offset = u.UpdateID + 1
+		if err := b.handle(ctx, u); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (b *Bot) handle(ctx context.Context, u botUpdate) error {
+	var chatID int64
+	var text string
+	switch {
+	case u.Message != nil:
+		chatID, text = u.Message.Chat.ID, u.Message.Text
+	case u.CallbackQuery != nil && u.CallbackQuery.Message != nil:
+		// кнопку нужно подтвердить, иначе клиент так и будет показывать ожидание
+		if err := b.call(ctx, "answerCallbackQuery", answerCallbackRequest{CallbackQueryID: u.CallbackQuery.ID}, nil); err != nil {
+			return err
+		}
+		chatID, text = u.CallbackQuery.Message.Chat.ID, u.CallbackQuery.Data
+	default:
+		return nil
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	s, fresh := b.session(chatID)
+	if !b.allow(s) {
+		if s.warned {
+			return nil
+		}
+		s.warned = true
+		return b.send(ctx, chatID, s.game.Translate("", tooManyCommands), nil)
+	}
+	if text == "/start" && !fresh {
+		s.game, fresh = b.newGame(), true
+	}
+	var replies []string
+	if fresh {
+		replies = append(replies, s.game.Translate("", greeting))
+	}
+	if text != "/start" {
+		reply := s.game.handleCommand(text)
+		replies = append(replies, reply)
+		if reply == s.game.Translate("", goodbye) {
+			delete(b.chats, chatID)
+			return b.send(ctx, chatID, strings.Join(replies, "\n"), nil)
+		}
+	}
+	if summary, over := s.game.Result(); over {
+		replies = append(replies, summary)
+		delete(b.chats, chatID)
+		return b.send(ctx, chatID, strings.Join(replies, "\n"), nil)
+	}
+	return b.send(ctx, chatID, strings.Join(replies, "\n"), b.keyboard(s.game))
+}
+
+// session возвращает игру чата, а если ее нет - начинает новую
+func (b *Bot) session(chatID int64) (*chatSession, bool) {
+	if b.chats == nil {
+		b.chats = make(map[int64]*chatSession)
+	}
+	if s, ok := b.chats[chatID]; ok {
+		s.lastSeen = b.clock()
+		return s, false
+	}
+	s := &chatSession{game: b.newGame(), lastSeen: b.clock()}
+	b.chats[chatID] = s
+	return s, true
+}
+
+func (b *Bot) newGame() *Game {
+	g := NewGame(b.World)
+	if b.Locale != "" {
+		g.SetLocale(b.Locale)
+	}
+	return g
+}
+
+// allow учитывает команду чата и решает, не слишком ли часто он пишет
+func (b *Bot) allow(s *chatSession) bool {
+	if b.RateLimit <= 0 {
+		return true
+	}
+	now := b.clock()
+	recent := s.recent[:0]
+	for _, t := range s.recent {
+		if now.Sub(t) < b.RateWindow {
+			recent = append(recent, t)
+		}
+	}
+	s.recent = recent
+	if len(s.recent) >= b.RateLimit {
+		return false
+	}
+	s.recent = append(s.recent, now)
+	s.warned = false
+	return true
+}
+
+// expire забывает игры, простоявшие дольше SessionTTL
+func (b *Bot) expire() {
+	if b.SessionTTL <= 0 {
+		return
+	}
+	now := b.clock()
+	for id, s := range b.chats {
+		if now.Sub(s.lastSeen) > b.SessionTTL {
+			delete(b.chats, id)
+		}
+	}
+}
+
+// keyboard - кнопки с доступными действиями, по две в ряд
+func (b *Bot) keyboard(g *Game) *inlineKeyboard {
+	var buttons []inlineButton
+	for _, action := range g.Actions() {
+		if len(buttons) == botButtons {
+			break
+		}
+		if len(action) > botButtonData {
+			continue
+		}
+		buttons = append(buttons, inlineButton{Text: action, CallbackData: action})
+	}
+	if len(buttons) == 0 {
+		return nil
+	}
+	kb := &inlineKeyboard{}
+	for i := 0; i < len(buttons); i += 2 {
+		end := i + 2
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		kb.InlineKeyboard = append(kb.InlineKeyboard, buttons[i:end])
+	}
+	return kb
+}
+
+func (b *Bot) send(ctx context.Context, chatID int64, text string, kb *inlineKeyboard) error {
+	return b.call(ctx, "sendMessage", sendMessageRequest{ChatID: chatID, Text: text, ReplyMarkup: kb}, nil)
+}
+
+// call вызывает метод Bot API и разбирает его результат в result
+func (b *Bot) call(ctx context.Context, method string, params, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(b.API, "/")+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := b.Client
+	if client == nil {
+		// запрос getUpdates висит до PollTimeout, таймаут клиента должен быть больше
+		client = &http.Client{Timeout: b.PollTimeout + 10*time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var r botResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return fmt.Errorf("%s: %s: %v", method, resp.Status, err)
+	}
+	if !r.OK {
+		return fmt.Errorf("%s: %s", method, r.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(r.Result, result)
+	}
+	return nil
+}
+
+func (b *Bot) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMessenger - мессенджер с Bot API на локальном сервере: отдает
+// заготовленные сообщения и запоминает ответы бота
+type fakeMessenger struct {
+	mu       sync.Mutex
+	updates  []botUpdate
+	nextID   int64
+	sent     []sendMessageRequest
+	answered []string
+	// fail - сколько следующих вызовов getUpdates вернут ошибку
+	fail int
+	srv  *httptest.Server
+}
+
+func newFakeMessenger(t *testing.T) *fakeMessenger {
+	m := &fakeMessenger{}
+	m.srv = httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *fakeMessenger) serve(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reply := func(result interface{}) {
+		data, _ := json.Marshal(result)
+		json.NewEncoder(w).Encode(botResponse{OK: true, Result: data})
+	}
+	switch strings.TrimPrefix(r.URL.Path, "/bottoken/") {
+	case "getUpdates":
+		if m.fail > 0 {
+			m.fail--
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(botResponse{Description: "bad gateway"})
+			return
+		}
+		var req getUpdatesRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		pending := []botUpdate{}
+		for _, u := range m.updates {
+			if u.UpdateID >= req.Offset {
+				pending = append(pending, u)
+			}
+		}
+		reply(pending)
+	case "sendMessage":
+		var req sendMessageRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		m.sent = append(m.sent, req)
+		reply(true)
+	case "answerCallbackQuery":
+		var req answerCallbackRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		m.answered = append(m.answered, req.CallbackQueryID)
+		reply(true)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(botResponse{Description: "not found"})
+	}
+}
+
+func (m *fakeMessenger) message(chat int64, text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	m.updates = append(m.updates, botUpdate{UpdateID: m.nextID, Message: &botMessage{Chat: botChat{ID: chat}, Text: text}})
+}
+
+func (m *fakeMessenger) press(chat int64, data string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	m.updates = append(m.updates, botUpdate{UpdateID: m.nextID, CallbackQuery: &botCallback{
+		ID: "cb" + data, Data: data, Message: &botMessage{Chat: botChat{ID: chat}},
+	}})
+}
+
+// takeSent забирает ответы бота, накопленные с прошлого вызова
+func (m *fakeMessenger) takeSent() []sendMessageRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sent := m.sent
+	m.sent = nil
+	return sent
+}
+
+func (m *fakeMessenger) bot(t *testing.T) *Bot {
+	return &Bot{World: testWorld(t), API: m.srv.URL + "/bottoken", Client: m.srv.Client()}
+}
+
+func buttons(kb *inlineKeyboard) []string {
+	if kb == nil {
+		return nil
+	}
+	var result []string
+	for _, row := range kb.InlineKeyboard {
+		for _, b := range row {
+			result = append(result, b.CallbackData)
+		}
+	}
+	return result
+}
+
+func pollOnce(t *testing.T, b *Bot) {
+	t.Helper()
+	if err := b.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBot_ChatsAndButtons(t *testing.T) {
+	m := newFakeMessenger(t)
+	b := m.bot(t)
+
+	m.message(1, "/start")
+	m.message(1, "идти коридор")
+	m.message(2, "осмотреться")
+	pollOnce(t, b)
+	sent := m.takeSent()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 replies, got %+v", sent)
+	}
+	if sent[0].ChatID != 1 || sent[0].Text != "Добро пожаловать в квест!" {
+		t.Errorf("unexpected greeting %+v", sent[0])
+	}
+	want := []string{"осмотреться", "инвентарь", "взять чай", "идти коридор"}
+	if got := buttons(sent[0].ReplyMarkup); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected buttons %v, got %v", want, got)
+	}
+	if sent[1].Text != "ничего интересного. можно пройти - кухня, комната, улица" {
+		t.Errorf("unexpected reply %q", sent[1].Text)
+	}
+	if got := buttons(sent[1].ReplyMarkup); !containsName(got, "идти комната") || !containsName(got, "идти улица") {
+		t.Errorf("hallway buttons must offer exits, got %v", got)
+	}
+	// второй чат играет свою игру и тоже получает приветствие
+	if sent[2].ChatID != 2 || !strings.HasPrefix(sent[2].Text, "Добро пожаловать в квест!\nты находишься на кухне") {
+		t.Errorf("unexpected reply to the second chat %+v", sent[2])
+	}
+
+	m.press(1, "идти комната")
+	pollOnce(t, b)
+	sent = m.takeSent()
+	if len(sent) != 1 || sent[0].Text != "ты в своей комнате. можно пройти - коридор" {
+		t.Fatalf("unexpected reply to button %+v", sent)
+	}
+	if len(m.answered) != 1 || m.answered[0] != "cbидти комната" {
+		t.Errorf("button press must be answered, got %v", m.answered)
+	}
+	if got := buttons(sent[0].ReplyMarkup); !containsName(got, "взять рюкзак") {
+		t.Errorf("room buttons must offer the backpack, got %v", got)
+	}
+
+	// /start начинает игру заново
+	m.message(1, "/start")
+	m.message(1, "осмотреться")
+	pollOnce(t, b)
+	sent = m.takeSent()
+	if len(sent) != 2 || !strings.HasPrefix(sent[1].Text, "ты находишься на кухне") {
+		t.Errorf("/start must restart the game, got %+v", sent)
+	}
+}
+
+func TestBot_FinishAndGoodbye(t *testing.T) {
+	m := newFakeMessenger(t)
+	b := m.bot(t)
+	for _, command := range []string{
+		"идти коридор", "идти комната", "надеть рюкзак", "взять ключи", "взять конспекты",
+		"идти коридор", "применить ключи дверь", "идти улица",
+	} {
+		m.message(1, command)
+	}
+	m.message(2, "выйти из игры")
+	pollOnce(t, b)
+	sent := m.takeSent()
+	last := sent[len(sent)-2]
+	if !strings.HasSuffix(last.Text, "победа! все цели выполнены, очки: 30 из 35") || last.ReplyMarkup != nil {
+		t.Errorf("unexpected final reply %+v", last)
+	}
+	goodbye := sent[len(sent)-1]
+	if goodbye.ChatID != 2 || !strings.HasSuffix(goodbye.Text, "Спасибо за игру!") || goodbye.ReplyMarkup != nil {
+		t.Errorf("unexpected goodbye %+v", goodbye)
+	}
+	if len(b.chats) != 0 {
+		t.Errorf("finished games must be forgotten, left %d", len(b.chats))
+	}
+}
+
+func TestBot_RateLimitAndExpiry(t *testing.T) {
+	m := newFakeMessenger(t)
+	b := m.bot(t)
+	b.RateLimit, b.RateWindow = 2, time.Minute
+	b.SessionTTL = 10 * time.Minute
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	m.message(1, "идти коридор")
+	m.message(1, "идти комната")
+	m.message(1, "осмотреться")
+	m.message(1, "инвентарь")
+	m.message(2, "идти коридор")
+	pollOnce(t, b)
+	sent := m.takeSent()
+	var texts []string
+	for _, s := range sent {
+		texts = append(texts, s.Text)
+	}
+	want := []string{
+		"Добро пожаловать в квест!\nничего интересного. можно пройти - кухня, комната, улица",
+		"ты в своей комнате. можно пройти - коридор",
+		"слишком много команд, подождите немного",
+		"Добро пожаловать в квест!\nничего интересного. можно пройти - кухня, комната, улица",
+	}
+	if strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("expected replies\n%q\ngot\n%q", want, texts)
+	}
+
+	// окно прошло - команды снова принимаются, игра продолжается
+	now = now.Add(time.Minute)
+	m.message(1, "идти коридор")
+	pollOnce(t, b)
+	if sent := m.takeSent(); len(sent) != 1 || sent[0].Text != "ничего интересного. можно пройти - кухня, комната, улица" {
+		t.Errorf("unexpected reply after the window %+v", sent)
+	}
+
+	// после долгого молчания игра забыта и начинается заново
+	now = now.Add(11 * time.Minute)
+	m.message(1, "идти комната")
+	pollOnce(t, b)
+	if sent := m.takeSent(); len(sent) != 1 || sent[0].Text != "Добро пожаловать в квест!\nнет пути в комната" {
+		t.Errorf("expired game must restart, got %+v", sent)
+	}
+	if _, ok := b.chats[2]; ok {
+		t.Error("idle chat 2 must be forgotten")
+	}
+}
+
+func TestBot_RunRetriesAndStops(t *testing.T) {
+	m := newFakeMessenger(t)
+	b := m.bot(t)
+	b.Locale = "en"
+	m.message(7, "go hallway")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- b.Run(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	var sent []sendMessageRequest
+	for len(sent) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		sent = m.takeSent()
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(sent) != 1 || sent[0].Text != "Welcome to the quest!\nnothing interesting. exits - kitchen, room, street" {
+		t.Fatalf("unexpected replies %+v", sent)
+	}
+	if got := buttons(sent[0].ReplyMarkup); !containsName(got, "go street") || !containsName(got, "look around") {
+		t.Errorf("english buttons expected, got %v", got)
+	}
+
+}
+
+func TestBot_MessengerErrors(t *testing.T) {
+	m := newFakeMessenger(t)
+	m.fail = 1
+	b := m.bot(t)
+	m.message(1, "идти коридор")
+	if err := b.Poll(context.Background()); err == nil || !strings.Contains(err.Error(), "bad gateway") {
+		t.Errorf("expected messenger error, got %v", err)
+	}
+	// следующий опрос забирает те же сообщения
+	pollOnce(t, b)
+	if sent := m.takeSent(); len(sent) != 1 || !strings.HasSuffix(sent[0].Text, "можно пройти - кухня, комната, улица") {
+		t.Errorf("message must survive the error, got %+v", sent)
+	}
+
+	b.API = m.srv.URL + "/wrong"
+	if err := b.Poll(context.Background()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected unknown method error, got %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// checkLimit - сколько состояний мира исследует проверка, прежде чем сдаться
+const checkLimit = 20000
+
+// Report - результат проверки мира на проходимость
+type Report struct {
+	// Winnable - все обязательные цели можно выполнить, Solution - кратчайший путь к победе
+	Winnable bool
+	Solution []string
+	// UnreachableRooms и UnreachableItems - куда игрок не попадет и что не сможет взять
+	UnreachableRooms []string
+	UnreachableItems []string
+	// DeadEnds - сколько найдено состояний, из которых уже не выиграть,
+	// DeadEnd - кратчайший путь в такое состояние
+	DeadEnds int
+	DeadEnd  []string
+	// States - сколько состояний исследовано, Truncated - исследованы не все
+	States    int
+	Truncated bool
+	// NoGoals - в мире нет целей: выиграть нельзя, но и проигрывать нечего,
+	// проверяется только достижимость
+	NoGoals bool
+}
+
+// move - команда, которую проверка пробует в состоянии
+type move struct {
+	text string
+	cmd  Command
+}
+
+// checkState - исследованное состояние мира и как в него попасть
+type checkState struct {
+	snapshot Snapshot
+	parent   int
+	command  string
+	next     []int
+	won      bool
+	over     bool
+}
+
+// Check перебирает все состояния мира, которых может достичь игрок одиночной игры,
+// в порядке числа команд. Пробуются команды, которые продвигают игру: взять, достать,
+// идти, применить, осмотреть (если осмотр что-то меняет), поговорить и ответить. Выбросить и положить только перекладывают
+// предметы и умножают число состояний, поэтому не пробуются
+func Check(w *World) Report {
+	g := NewGame(w)
+	// без часов и событий номер хода не влияет на мир, и одинаковые состояния склеиваются
+	timed := w.Clock != nil || len(w.Events) > 0
+
+	report := Report{NoGoals: len(w.Goals) == 0}
+	var states []*checkState
+	index := make(map[string]int)
+	// visit запоминает новое состояние, а когда их уже checkLimit - отмечает,
+	// что исследованы не все, и возвращает parent
+	visit := func(s Snapshot, parent int, command string) (int, bool) {
+		key := stateKey(s, timed)
+		if i, ok := index[key]; ok {
+			return i, false
+		}
+		if len(states) >= checkLimit {
+			report.Truncated = true
+			return parent, false
+		}
+		states = append(states, &checkState{snapshot: s, parent: parent, command: command})
+		index[key] = len(states) - 1
+		return len(states) - 1, true
+	}
+
+	rooms := make(map[string]bool)
+	items := make(map[string]bool)
+	visit(g.snapshot(), -1, "")
+	for i := 0; i < len(states) && !report.Truncated; i++ {
+		st := states[i]
+		g.restore(st.snapshot)
+		rooms[g.player.room.Name] = true
+		for _, item := range g.player.allItems() {
+			items[item] = true
+		}
+		if _, over := g.outcome(g.player); over {
+			st.over = true
+			st.won = g.player.failed == ""
+			continue
+		}
+		for _, m := range g.moves(g.player) {
+			g.restore(st.snapshot)
+			g.act(m.cmd, g.player)
+			j, _ := visit(g.snapshot(), i, m.text)
+			if j != i {
+				st.next = append(st.next, j)
+			}
+		}
+	}
+	report.States = len(states)
+
+	// кратчайший путь к победе - первое выигрышное состояние в порядке обхода
+	for i, st := range states {
+		if st.won {
+			report.Winnable = true
+			report.Solution = path(states, i)
+			break
+		}
+	}
+
+	// тупики - состояния, из которых ни одно выигрышное состояние не достижимо
+	if !report.NoGoals && !report.Truncated {
+		canWin := make([]bool, len(states))
+		for changed := true; changed; {
+			changed = false
+			for i, st := range states {
+				if canWin[i] {
+					continue
+				}
+				ok := st.won
+				for _, j := range st.next {
+					ok = ok || canWin[j]
+				}
+				if ok {
+					canWin[i], changed = true, true
+				}
+			}
+		}
+		for i, st := range states {
+			if canWin[i] || (st.over && !st.won) {
+				continue
+			}
+			if report.DeadEnds == 0 {
+				report.DeadEnd = path(states, i)
+			}
+			report.DeadEnds++
+		}
+	}
+
+	if !report.Truncated {
+		for _, r := range w.Rooms {
+			if !rooms[r.Name] {
+				report.UnreachableRooms = append(report.UnreachableRooms, r.Name)
+			}
+		}
+		for _, item := range worldItems(w) {
+			if !items[item] && !w.item(item).Fixed {
+				report.UnreachableItems = append(report.UnreachableItems, item)
+			}
+		}
+	}
+	return report
+}
+
+// stateKey - ключ состояния для склейки одинаковых состояний. Порядок,
+// в котором лежат предметы, и посещенные комнаты на проходимость
+// не влияют и в ключ не входят
+func stateKey(s Snapshot, timed bool) string {
+	if !timed {
+		s.Turn = 0
+	}
+	s.Player = sortedPlayer(s.Player)
+	s.Player.Visited = nil
+	rooms := make([]RoomState, len(s.Rooms))
+	for i, r := range s.Rooms {
+		r.Items = sortedCopy(r.Items)
+		r.Contents = sortedContents(r.Contents)
+		rooms[i] = r
+	}
+	s.Rooms = rooms
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func sortedPlayer(p PlayerState) PlayerState {
+	p.Items = sortedCopy(p.Items)
+	p.Contents = sortedContents(p.Contents)
+	return p
+}
+
+func sortedContents(contents map[string][]string) map[string][]string {
+	if contents == nil {
+		return nil
+	}
+	result := make(map[string][]string, len(contents))
+	for container, items := range contents {
+		result[container] = sortedCopy(items)
+	}
+	return result
+}
+
+func sortedCopy(items []string) []string {
+	result := append([]string{}, items...)
+	sort.Strings(result)
+	return result
+}
+
+func path(states []*checkState, i int) []string {
+	var commands []string
+	for ; states[i].parent >= 0; i = states[i].parent {
+		commands = append(commands, states[i].command)
+	}
+	for l, r := 0, len(commands)-1; l < r; l, r = l+1, r-1 {
+		commands[l], commands[r] = commands[r], commands[l]
+	}
+	return commands
+}
+
+// worldItems - все предметы мира: лежащие в комнатах и выдаваемые эффектами
+func worldItems(w *World) []string {
+	seen := make(map[string]bool)
+	var result []string
+	add := func(item string) {
+		if item != "" && !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	for _, r := range w.Rooms {
+		for _, item := range r.Items {
+			add(item)
+		}
+		for _, item := range r.Hidden {
+			add(item)
+		}
+		containers := make([]string, 0, len(r.Contents))
+		for container := range r.Contents {
+			containers = append(containers, container)
+		}
+		sort.Strings(containers)
+		for _, container := range containers {
+			for _, item := range r.Contents[container] {
+				add(item)
+			}
+		}
+	}
+	rc := &ruleChecker{rooms: map[string]bool{}, items: map[string]bool{}, flags: map[string]bool{}, counters: map[string]bool{}}
+	for _, r := range w.Rooms {
+		for _, obj := range r.Objects {
+			rc.collectRules(obj)
+		}
+	}
+	for _, npc := range w.NPCs {
+		for _, node := range npc.Dialogue {
+			rc.collectEffects(node.Effects)
+			for _, choice := range node.Choices {
+				rc.collectEffects(choice.Effects)
+			}
+		}
+	}
+	for _, e := range w.Events {
+		rc.collectEffects(e.Effects)
+	}
+	given := make([]string, 0, len(rc.items))
+	for item := range rc.items {
+		given = append(given, item)
+	}
+	sort.Strings(given)
+	for _, item := range given {
+		add(item)
+	}
+	return result
+}
+
+// moves - команды, которые стоит попробовать игроку в текущем состоянии
+func (g *Game) moves(player *Player) []move {
+	var result []move
+	add := func(cmd Command) {
+		result = append(result, move{text: russian.format(cmd), cmd: cmd})
+	}
+	room := player.room
+
+	if player.dialogue != nil {
+		if npc, ok := room.npcIn(player.dialogue.NPC); ok {
+			for i := range g.choicesFor(player, npc.Dialogue[player.dialogue.Node]) {
+				add(Command{Verb: "answer", Object: strconv.Itoa(i + 1)})
+			}
+		}
+		return result
+	}
+
+	for _, item := range room.Items {
+		if !g.world.item(item).Fixed {
+			add(Command{Verb: "take", Object: item})
+		}
+	}
+	for _, container := range room.allItems() {
+		for _, item := range room.Contents[container] {
+			add(Command{Verb: "takeout", Object: item, Target: container})
+		}
+	}
+	for _, exit := range room.exits() {
+		add(Command{Verb: "go", Object: exit})
+	}
+	for _, name := range room.objectOrder {
+		obj, ok := room.Objects[name]
+		if !ok {
+			continue
+		}
+		looked := false
+		changed := make(map[ActionType]bool)
+		for _, action := range obj.Actions {
+			if verb, ok := stateVerbs[action.action]; ok && action.allows(obj.State) && !changed[action.action] {
+				add(Command{Verb: verb, Object: name})
+				changed[action.action] = true
+			}
+			if action.action == ActionUse && player.hasItem(action.item) {
+				add(Command{Verb: "use", Object: action.item, Target: name})
+			}
+			if action.action == ActionLook && len(action.effects) > 0 && !looked {
+				add(Command{Verb: "examine", Object: name})
+				looked = true
+			}
+		}
+	}
+	for _, npc := range room.npcNames() {
+		add(Command{Verb: "talk", Object: npc})
+	}
+	for _, r := range g.world.Recipes {
+		if player.hasItem(r.Items[0]) && player.hasItem(r.Items[1]) && player.hasTool(r.Tool) {
+			add(Command{Verb: "combine", Object: r.Items[0], Target: r.Items[1]})
+		}
+	}
+	return result
+}
+
+// stateVerbs - команды смены состояния объекта
+var stateVerbs = map[ActionType]string{ActionOpen: "open", ActionClose: "close", ActionLock: "lock", ActionBreak: "break"}
+
+// String - отчет о проверке для автора мира
+func (r Report) String() string {
+	var b strings.Builder
+	switch {
+	case r.NoGoals:
+		b.WriteString("в мире нет целей, проверена только достижимость\n")
+	case r.Winnable:
+		fmt.Fprintf(&b, "мир проходим за %d команд: %s\n", len(r.Solution), strings.Join(r.Solution, ", "))
+	case r.Truncated:
+		b.WriteString("победа не найдена среди исследованных состояний\n")
+	default:
+		b.WriteString("мир непроходим: выиграть нельзя\n")
+	}
+	fmt.Fprintf(&b, "недостижимые комнаты: %s\n", listOrDash(r.UnreachableRooms))
+	fmt.Fprintf(&b, "недостижимые предметы: %s\n", listOrDash(r.UnreachableItems))
+	switch {
+	case r.DeadEnds > 0 && len(r.DeadEnd) == 0:
+		fmt.Fprintf(&b, "тупики: %d, в том числе начальное состояние\n", r.DeadEnds)
+	case r.DeadEnds > 0:
+		fmt.Fprintf(&b, "тупики: %d, например после: %s\n", r.DeadEnds, strings.Join(r.DeadEnd, ", "))
+	default:
+		b.WriteString("тупики: -\n")
+	}
+	fmt.Fprintf(&b, "исследовано состояний: %d", r.States)
+	if r.Truncated {
+		b.WriteString(" (не все)")
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ключ можно расплавить в печи, и тогда дверь уже не открыть, а в подвал не ведет ни один выход
+const furnaceWorld = `{
+	"start": "склад",
+	"items": [{"name": "карман", "capacity": 1, "wear": "пояс"}, {"name": "ключ", "weight": 1}],
+	"goals": [{"name": "выйти", "points": 1, "done": {"at": "улица"}}],
+	"rooms": [
+		{"name": "склад", "description": "склад", "items": ["карман", "ключ"], "objects": [
+			{"name": "дверь", "actions": [
+				{"type": "use", "item": "ключ", "commentary": "дверь открыта", "effects": [{"setFlag": "открыто"}]},
+				{"type": "go", "to": "улица", "if": {"flag": "открыто"}}
+			]},
+			{"name": "печь", "actions": [
+				{"type": "use", "item": "ключ", "commentary": "ключ расплавился", "effects": [{"take": "ключ"}]}
+			]}
+		]},
+		{"name": "улица", "description": "улица", "items": []},
+		{"name": "подвал", "description": "подвал", "items": ["фонарь"]}
+	]
+}`
+
+func TestCheck_DefaultWorld(t *testing.T) {
+	report := Check(testWorld(t))
+	if !report.Winnable || report.Truncated {
+		t.Fatalf("default world must be winnable: %s", report)
+	}
+	want := []string{"идти коридор", "идти комната", "взять рюкзак", "взять ключи", "взять конспекты",
+		"идти коридор", "применить ключи дверь", "идти улица"}
+	if !reflect.DeepEqual(report.Solution, want) {
+		t.Errorf("expected solution %v\n\tgot %v", want, report.Solution)
+	}
+	if report.DeadEnds != 0 || len(report.UnreachableRooms) != 0 || len(report.UnreachableItems) != 0 {
+		t.Errorf("unexpected problems: %s", report)
+	}
+
+	// найденное решение действительно выигрывает
+	g := NewGame(testWorld(t))
+	for _, command := range report.Solution {
+		g.handleCommand(command)
+	}
+	if _, over := g.Result(); !over {
+		t.Error("solution does not finish the game")
+	}
+}
+
+func TestCheck_DeadEndsAndUnreachable(t *testing.T) {
+	w, err := ParseWorld([]byte(furnaceWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Check(w)
+	if !report.Winnable {
+		t.Fatalf("world must be winnable: %s", report)
+	}
+	if want := []string{"подвал"}; !reflect.DeepEqual(report.UnreachableRooms, want) {
+		t.Errorf("unreachable rooms: expected %v got %v", want, report.UnreachableRooms)
+	}
+	if want := []string{"фонарь"}; !reflect.DeepEqual(report.UnreachableItems, want) {
+		t.Errorf("unreachable items: expected %v got %v", want, report.UnreachableItems)
+	}
+	if want := []string{"взять карман", "взять ключ", "применить ключ печь"}; !reflect.DeepEqual(report.DeadEnd, want) {
+		t.Errorf("dead end: expected %v got %v", want, report.DeadEnd)
+	}
+	if !strings.Contains(report.String(), "тупики: 1, например после: взять карман, взять ключ, применить ключ печь") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
+
+func TestCheck_Unwinnable(t *testing.T) {
+	// ключ заперт в подвале, дверь не открыть: начальное состояние уже тупик
+	data := strings.Replace(furnaceWorld, `"items": ["карман", "ключ"]`, `"items": ["карман"]`, 1)
+	data = strings.Replace(data, `"items": ["фонарь"]`, `"items": ["ключ", "фонарь"]`, 1)
+	w, err := ParseWorld([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Check(w)
+	if report.Winnable || report.Truncated || len(report.Solution) != 0 {
+		t.Fatalf("world must be unwinnable: %s", report)
+	}
+	if !strings.HasPrefix(report.String(), "мир непроходим: выиграть нельзя\n") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+	if want := []string{"ключ", "фонарь"}; !reflect.DeepEqual(report.UnreachableItems, want) {
+		t.Errorf("unreachable items: expected %v got %v", want, report.UnreachableItems)
+	}
+}
+
+func TestCheck_NoGoals(t *testing.T) {
+	// без целей выигрывать нечего: это не «непроходимый» мир, тупиков нет, но достижимость проверяется
+	data := strings.Replace(furnaceWorld, `"goals": [{"name": "выйти", "points": 1, "done": {"at": "улица"}}],`, "", 1)
+	w, err := ParseWorld([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Check(w)
+	if !report.NoGoals || report.Winnable || report.DeadEnds != 0 {
+		t.Fatalf("world must have no goals: %+v", report)
+	}
+	if !strings.HasPrefix(report.String(), "в мире нет целей, проверена только достижимость\n") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+	if want := []string{"подвал"}; !reflect.DeepEqual(report.UnreachableRooms, want) {
+		t.Errorf("unreachable rooms: expected %v got %v", want, report.UnreachableRooms)
+	}
+}
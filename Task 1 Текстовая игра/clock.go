@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClockDef - игровые часы: время на старте и сколько минут проходит за ход.
+// Periods делят сутки на части, по которым условия выбирают описания
+type ClockDef struct {
+	Start   string   `json:"start"`
+	Step    int      `json:"step"`
+	Periods []Period `json:"periods,omitempty"`
+}
+
+// Period - часть суток, начинается в From ("06:00") и длится до следующей
+type Period struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+}
+
+// EventDef - событие по расписанию: срабатывает на ходу At и, если задано Every,
+// повторяется каждые Every ходов. Без условия If событие срабатывает всегда
+type EventDef struct {
+	At      int        `json:"at,omitempty"`
+	Every   int        `json:"every,omitempty"`
+	If      *Condition `json:"if,omitempty"`
+	Effects []Effect   `json:"effects,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+// Timer - эффекты, отложенные действием: в описании мира задается In -
+// через сколько ходов, в игре - At, ход, на котором таймер сработает
+type Timer struct {
+	In      int      `json:"in,omitempty"`
+	At      int      `json:"at,omitempty"`
+	Effects []Effect `json:"effects,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// validateClock проверяет часы и запоминает части суток для проверки условий
+func (w *World) validateClock(rc *ruleChecker, addErr func(format string, args ...interface{})) {
+	if w.Clock == nil {
+		return
+	}
+	if _, ok := parseClock(w.Clock.Start); !ok {
+		addErr("часы: некорректное время начала %q", w.Clock.Start)
+	}
+	if w.Clock.Step < 0 {
+		addErr("часы: ход не может отнимать время")
+	}
+	for _, p := range w.Clock.Periods {
+		if p.Name == "" {
+			addErr("часы: часть суток без имени")
+			continue
+		}
+		if rc.periods[p.Name] {
+			addErr("часы: часть суток %q описана несколько раз", p.Name)
+		}
+		rc.periods[p.Name] = true
+		if _, ok := parseClock(p.From); !ok {
+			addErr("часы: некорректное время начала части суток %q", p.Name)
+		}
+	}
+}
+
+// parseClock разбирает время "ЧЧ:ММ" в минуты от полуночи
+func parseClock(s string) (int, bool) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// minutes - время суток в минутах от полуночи на текущем ходу
+func (g *Game) minutes() int {
+	clock := g.world.Clock
+	if clock == nil {
+		return 0
+	}
+	start, _ := parseClock(clock.Start)
+	return (start + g.turn*clock.Step) % (24 * 60)
+}
+
+// period - текущая часть суток. До первой части дня продолжается последняя
+func (g *Game) period() string {
+	clock := g.world.Clock
+	if clock == nil || len(clock.Periods) == 0 {
+		return ""
+	}
+	now := g.minutes()
+	current, currentFrom := "", -1
+	latest, latestFrom := "", -1
+	for _, p := range clock.Periods {
+		from, _ := parseClock(p.From)
+		if from <= now && from > currentFrom {
+			current, currentFrom = p.Name, from
+		}
+		if from > latestFrom {
+			latest, latestFrom = p.Name, from
+		}
+	}
+	if current == "" {
+		return latest
+	}
+	return current
+}
+
+// timeText - команда "время"
+func (g *Game) timeText(player *Player) string {
+	text := g.tr(player, "ход %d", g.turn)
+	if g.world.Clock == nil {
+		return text
+	}
+	now := g.minutes()
+	text += fmt.Sprintf(", %02d:%02d", now/60, now%60)
+	if period := g.period(); period != "" {
+		text += ", " + g.local(player, period)
+	}
+	return text
+}
+
+// schedule откладывает эффекты таймера на t.In ходов после текущего. Флаги,
+// замены и описания относятся к комнате, где таймер заведен, а не к той,
+// где игрок окажется потом
+func (g *Game) schedule(t Timer, player *Player) {
+	timer := Timer{At: g.turn + 1 + t.In, Message: t.Message}
+	for _, e := range t.Effects {
+		if e.Room == "" {
+			e.Room = player.room.Name
+		}
+		timer.Effects = append(timer.Effects, e)
+	}
+	g.timers = append(g.timers, timer)
+}
+
+// tick завершает ход: двигает часы, запускает события и таймеры
+// и возвращает их сообщения
+func (g *Game) tick(player *Player) []string {
+	g.turn++
+	var messages []string
+	for _, e := range g.world.Events {
+		if !e.due(g.turn) || !g.check(e.If, player) {
+			continue
+		}
+		g.apply(e.Effects, player)
+		if e.Message != "" {
+			messages = append(messages, e.Message)
+		}
+	}
+	// эффекты сработавших таймеров могут заводить новые, они попадут в g.timers
+	timers := g.timers
+	g.timers = nil
+	for _, t := range timers {
+		if t.At > g.turn {
+			g.timers = append(g.timers, t)
+			continue
+		}
+		g.apply(t.Effects, player)
+		if t.Message != "" {
+			messages = append(messages, t.Message)
+		}
+	}
+	return messages
+}
+
+// due - срабатывает ли событие на ходу turn
+func (e EventDef) due(turn int) bool {
+	first := e.At
+	if first == 0 {
+		first = e.Every
+	}
+	if turn < first {
+		return false
+	}
+	if turn == first {
+		return true
+	}
+	return e.Every > 0 && (turn-first)%e.Every == 0
+}
+
+// withEvents дописывает к ответу сообщения событий хода
+func withEvents(reply string, messages []string) string {
+	if len(messages) == 0 {
+		return reply
+	}
+	if reply == "" {
+		return strings.Join(messages, ". ")
+	}
+	return reply + ". " + strings.Join(messages, ". ")
+}
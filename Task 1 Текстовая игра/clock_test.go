@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const busWorld = `{
+	"start": "дом",
+	"items": [{"name": "карман", "capacity": 1, "wear": "пояс"}, {"name": "ключ", "weight": 1}],
+	"clock": {"start": "05:50", "step": 5, "periods": [{"name": "ночь", "from": "22:00"}, {"name": "утро", "from": "06:00"}]},
+	"events": [
+		{"at": 4, "message": "вдали уезжает автобус", "effects": [
+			{"room": "остановка", "swap": {"name": "автобус", "actions": []}},
+			{"room": "остановка", "describe": "автобус уехал без тебя"}
+		]},
+		{"every": 5, "if": {"at": "остановка"}, "message": "мимо проезжает машина"}
+	],
+	"rooms": [
+		{"name": "дом", "description": "дома", "items": ["карман", "ключ"],
+			"notes": [{"if": {"time": "ночь"}, "text": "темно"}, {"text": "светло"}],
+			"objects": [{"name": "дверь", "exit": "остановка", "actions": [
+				{"type": "use", "item": "ключ", "commentary": "дверь открыта", "effects": [
+					{"setFlag": "открыто"},
+					{"schedule": {"in": 2, "message": "дверь захлопнулась", "effects": [{"clearFlag": "открыто"}]}}
+				]},
+				{"type": "go", "to": "остановка", "if": {"flag": "открыто"}, "fail": "дверь закрыта"}
+			]}]},
+		{"name": "остановка", "description": "остановка", "items": [], "objects": [
+			{"name": "автобус", "actions": [{"type": "go", "to": "дом"}]},
+			{"name": "домой", "actions": [{"type": "go", "to": "дом"}]}
+		]}
+	]
+}`
+
+func TestClock_EventsAndTimers(t *testing.T) {
+	w, err := ParseWorld([]byte(busWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	playSteps(t, g, []gameCase{
+		{1, "осмотреться", "дома, на полу: карман, ключ, темно. можно пройти - остановка"},
+		{2, "время", "ход 1, 05:55, ночь"},
+		// нераспознанная команда не отнимает хода
+		{3, "завтракать", "неизвестная команда"},
+		{4, "осмотреться", "дома, на полу: карман, ключ, светло. можно пройти - остановка"},
+		{5, "надеть карман", "вы надели: карман. вдали уезжает автобус"},
+		{6, "взять ключ", "предмет добавлен в инвентарь: ключ"},
+		{7, "применить ключ дверь", "дверь открыта"},
+		{8, "время", "ход 6, 06:20, утро"},
+		{9, "осмотреться", "дома, светло. можно пройти - остановка. дверь захлопнулась"},
+		{10, "идти остановка", "дверь закрыта"},
+		// на ходу 10 машина проезжает мимо остановки, а игрок дома
+		{11, "применить ключ дверь", "дверь открыта"},
+	})
+
+	// таймер переживает сохранение
+	restored := NewGame(w)
+	if err := restored.Restore(g.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if restored.turn != 10 || len(restored.timers) != 1 {
+		t.Errorf("clock was not restored: turn %d timers %v", restored.turn, restored.timers)
+	}
+
+	playSteps(t, g, []gameCase{
+		{12, "идти остановка", "автобус уехал без тебя. можно пройти - домой"},
+		// дверь захлопывается, даже если игрок уже ушел
+		{13, "время", "ход 11, 06:45, утро. дверь захлопнулась"},
+		{14, "время", "ход 12, 06:50, утро"},
+		{15, "время", "ход 13, 06:55, утро"},
+		{16, "время", "ход 14, 07:00, утро. мимо проезжает машина"},
+	})
+}
+
+func TestClock_Validation(t *testing.T) {
+	data := `{
+		"start": "дом",
+		"clock": {"start": "25:00", "step": -1, "periods": [{"name": "утро", "from": "6"}, {"name": "утро", "from": "07:00"}]},
+		"events": [{"message": "никогда"}],
+		"rooms": [{"name": "дом", "items": [], "notes": [{"if": {"time": "полдень"}, "text": "жарко"}],
+			"objects": [{"name": "кнопка", "actions": [{"type": "go", "to": "дом", "effects": [{"schedule": {"effects": [{"move": "чердак"}]}}]}]}]}]
+	}`
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		`часы: некорректное время начала "25:00"`,
+		`часы: ход не может отнимать время`,
+		`часы: некорректное время начала части суток "утро"`,
+		`часы: часть суток "утро" описана несколько раз`,
+		`событие 1: не указано, на каком ходу оно срабатывает`,
+		`неизвестная часть суток "полдень"`,
+		`таймер должен срабатывать хотя бы через ход`,
+		`эффект 1, таймер, эффект 1: неизвестная комната "чердак"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q not reported:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Recipe - что получается, если соединить два предмета
+type Recipe struct {
+	// Items - соединяемые предметы, порядок не важен
+	Items  []string `json:"items"`
+	Result string   `json:"result"`
+	// Tool - без чего не обойтись: предмет у игрока или в комнате, он не расходуется
+	Tool string `json:"tool,omitempty"`
+	// Keep - какие из предметов остаются у игрока, остальные расходуются
+	Keep       []string `json:"keep,omitempty"`
+	Commentary string   `json:"commentary,omitempty"`
+}
+
+// recipe ищет рецепт для пары предметов
+func (w *World) recipe(a, b string) (*Recipe, bool) {
+	for i, r := range w.Recipes {
+		if (r.Items[0] == a && r.Items[1] == b) || (r.Items[0] == b && r.Items[1] == a) {
+			return &w.Recipes[i], true
+		}
+	}
+	return nil, false
+}
+
+// hasTool - инструмент под рукой: у игрока или в комнате
+func (player *Player) hasTool(tool string) bool {
+	return tool == "" || player.hasItem(tool) || player.room.hasItem(tool)
+}
+
+// exists - есть ли предмет где-нибудь в мире: у игроков или в комнатах
+func (g *Game) exists(item string) bool {
+	if g.player.hasItem(item) {
+		return true
+	}
+	for _, p := range g.players {
+		if p.hasItem(item) {
+			return true
+		}
+	}
+	for _, room := range g.rooms {
+		if room.hasItem(item) || containsName(room.hidden, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// combine - команда "соединить X и Y": оба предмета должны быть у игрока
+func (g *Game) combine(player *Player, cmd Command) string {
+	for _, item := range []string{cmd.Object, cmd.Target} {
+		if !player.hasItem(item) {
+			return g.reject(player, cmd, g.tr(player, "нет предмета в инвентаре - %s", g.local(player, item)))
+		}
+	}
+	if cmd.Object == cmd.Target {
+		return g.reject(player, cmd, g.tr(player, "нельзя соединить предмет с самим собой"))
+	}
+	recipe, ok := g.world.recipe(cmd.Object, cmd.Target)
+	if !ok {
+		return g.reject(player, cmd, g.tr(player, "из этого ничего не выйдет"))
+	}
+	if !player.hasTool(recipe.Tool) {
+		return g.reject(player, cmd, g.tr(player, "нужен предмет - %s", g.local(player, recipe.Tool)))
+	}
+	// имена предметов уникальны: второй раз тот же результат не получить
+	if g.exists(recipe.Result) {
+		return g.reject(player, cmd, g.tr(player, "такой предмет уже есть - %s", g.local(player, recipe.Result)))
+	}
+	for _, item := range recipe.Items {
+		if !containsName(recipe.Keep, item) {
+			player.removeItem(item)
+		}
+	}
+	// результат убирается как выданный предмет, а если места нет - остается в руках
+	container, _ := g.placeFor(&player.Inventory, recipe.Result, g.world.item(recipe.Result).Weight, "")
+	player.attach(recipe.Result, container, nil)
+	if recipe.Commentary != "" {
+		return g.local(player, recipe.Commentary)
+	}
+	return g.tr(player, "получено: %s", g.local(player, recipe.Result))
+}
+
+// validateRecipes проверяет рецепты: два разных известных предмета, известные
+// инструмент и результат. Контейнер расходовать нельзя - пропало бы его содержимое
+func (w *World) validateRecipes(rc *ruleChecker, addErr func(format string, args ...interface{})) {
+	for i, r := range w.Recipes {
+		where := fmt.Sprintf("рецепт %d", i+1)
+		if len(r.Items) != 2 || r.Items[0] == r.Items[1] {
+			addErr("%s: нужно два разных предмета", where)
+			continue
+		}
+		for _, item := range r.Items {
+			rc.checkItem(where, item)
+			if !containsName(r.Keep, item) && w.item(item).Capacity > 0 {
+				addErr("%s: контейнер %q нельзя израсходовать", where, item)
+			}
+		}
+		for _, item := range r.Keep {
+			if !containsName(r.Items, item) {
+				addErr("%s: остается %q, которого нет среди предметов", where, item)
+			}
+		}
+		if r.Result == "" {
+			addErr("%s: не указано, что получается", where)
+		}
+		rc.checkItem(where, r.Tool)
+		for j := 0; j < i; j++ {
+			other := w.Recipes[j].Items
+			if len(other) == 2 && containsName(other, r.Items[0]) && containsName(other, r.Items[1]) {
+				addErr("%s: %q и %q уже соединяются в рецепте %d", where, r.Items[0], r.Items[1], j+1)
+			}
+		}
+	}
+}
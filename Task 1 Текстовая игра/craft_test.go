@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// чай заваривается только при чайнике, а спички после свечи остаются
+const teaWorld = `{
+	"start": "кухня",
+	"items": [
+		{"name": "сумка", "capacity": 4, "wear": "плечо"},
+		{"name": "чай", "weight": 1},
+		{"name": "кружка", "weight": 1},
+		{"name": "чашка чая", "weight": 2},
+		{"name": "чайник", "fixed": true},
+		{"name": "спички", "weight": 1},
+		{"name": "свеча", "weight": 1},
+		{"name": "горящая свеча", "weight": 1}
+	],
+	"recipes": [
+		{"items": ["чай", "кружка"], "result": "чашка чая", "tool": "чайник", "commentary": "вы заварили чай"},
+		{"items": ["спички", "свеча"], "result": "горящая свеча", "keep": ["спички"]}
+	],
+	"goals": [{"name": "чаепитие", "points": 1, "done": {"all": [{"has": "чашка чая"}, {"has": "горящая свеча"}]}}],
+	"rooms": [
+		{"name": "кухня", "description": "кухня", "items": ["сумка", "чай", "кружка", "спички"], "objects": [
+			{"name": "дверь", "actions": [{"type": "go", "to": "кладовка"}]}
+		]},
+		{"name": "кладовка", "description": "кладовка", "items": ["свеча", "чайник"], "objects": [
+			{"name": "дверь", "actions": [{"type": "go", "to": "кухня"}]}
+		]}
+	]
+}`
+
+func TestCraft_Recipes(t *testing.T) {
+	w, err := ParseWorld([]byte(teaWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	playSteps(t, g, []gameCase{
+		{1, "соединить чай и кружку", "нет предмета в инвентаре - чай"},
+		{2, "взять сумку", "вы надели: сумка"},
+		{3, "взять чай", "предмет добавлен в инвентарь: чай"},
+		{4, "взять кружку", "предмет добавлен в инвентарь: кружка"},
+		{5, "соединить чай", "укажите, что с чем соединить"},
+		{6, "соединить чай с чаем", "нельзя соединить предмет с самим собой"},
+		{7, "соединить чай и сумку", "из этого ничего не выйдет"},
+		{8, "соединить чай и кружку", "нужен предмет - чайник"},
+		{9, "идти дверь", "кладовка, на полу: свеча, чайник. можно пройти - дверь"},
+		{10, "соединить кружку с чаем", "вы заварили чай"},
+		{11, "инвентарь", "надето: сумка, сумка: чашка чая"},
+		{12, "взять свечу", "предмет добавлен в инвентарь: свеча"},
+		{13, "идти дверь", "кухня, на полу: спички. можно пройти - дверь"},
+		{14, "взять спички", "предмет добавлен в инвентарь: спички"},
+		{15, "соединить спички свечу", "получено: горящая свеча"},
+		{16, "инвентарь", "надето: сумка, сумка: чашка чая, спички, горящая свеча"},
+		{17, "отменить", "отменено: соединить спички свечу"},
+		{18, "language en", "language: English"},
+		{19, "combine спички and свеча", "you made: горящая свеча"},
+	})
+	if summary, over := g.Result(); !over || !strings.HasPrefix(summary, "victory") {
+		t.Errorf("crafted goal must be done: %q", summary)
+	}
+}
+
+func TestCraft_ResultIsUnique(t *testing.T) {
+	// оба предмета остаются - второй раз соединить их нельзя
+	data := strings.Replace(teaWorld, `"keep": ["спички"]`, `"keep": ["спички", "свеча"]`, 1)
+	w, err := ParseWorld([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	playSteps(t, g, []gameCase{
+		{1, "взять сумку", "вы надели: сумка"},
+		{2, "взять спички", "предмет добавлен в инвентарь: спички"},
+		{3, "идти дверь", "кладовка, на полу: свеча, чайник. можно пройти - дверь"},
+		{4, "взять свечу", "предмет добавлен в инвентарь: свеча"},
+		{5, "соединить спички и свечу", "получено: горящая свеча"},
+		{6, "соединить спички и свечу", "такой предмет уже есть - горящая свеча"},
+		{7, "выбросить горящую свечу", "предмет оставлен в комнате: горящая свеча"},
+		{8, "соединить спички и свечу", "такой предмет уже есть - горящая свеча"},
+	})
+	if got := g.player.allItems(); strings.Join(got, ", ") != "сумка, спички, свеча" {
+		t.Errorf("unexpected inventory %v", got)
+	}
+}
+
+func TestCraft_CheckerCombines(t *testing.T) {
+	w, err := ParseWorld([]byte(teaWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Check(w)
+	if !report.Winnable {
+		t.Fatalf("world must be winnable: %s", report)
+	}
+	solution := strings.Join(report.Solution, ", ")
+	for _, want := range []string{"соединить чай и кружка", "соединить спички и свеча"} {
+		if !strings.Contains(solution, want) {
+			t.Errorf("solution %q must contain %q", solution, want)
+		}
+	}
+}
+
+func TestCraft_Validation(t *testing.T) {
+	data := strings.Replace(teaWorld, `"result": "горящая свеча", "keep": ["спички"]`, `"result": "горящая свеча", "keep": ["сумка"]`, 1)
+	data = strings.Replace(data, `"tool": "чайник"`, `"tool": "самовар"`, 1)
+	data = strings.Replace(data, `"recipes": [`, `"recipes": [{"items": ["сумка", "свеча"], "result": "фонарь"}, {"items": ["кружка"], "result": "чай"}, {"items": ["кружка", "чай"], "result": "кофе"},`, 1)
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected recipe errors")
+	}
+	for _, want := range []string{
+		`рецепт 1: контейнер "сумка" нельзя израсходовать`,
+		`рецепт 2: нужно два разных предмета`,
+		`рецепт 4: неизвестный предмет "самовар"`,
+		`рецепт 4: "чай" и "кружка" уже соединяются в рецепте 3`,
+		`рецепт 5: остается "сумка", которого нет среди предметов`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q in\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+)
+
+const defaultPlace = "на полу"
+
+// describe собирает описание комнаты таким, каким его видит игрок:
+// постоянная часть, предметы по местам, приписка и выходы
+func (g *Game) describe(room *Room, player *Player) string {
+	var parts []string
+	if room.Description != "" {
+		parts = append(parts, g.local(player, room.Description))
+	}
+	if items := g.itemsText(room, player); items != "" {
+		parts = append(parts, items)
+	} else if room.empty != "" {
+		parts = append(parts, g.local(player, room.empty))
+	}
+	if npcs := room.npcNames(); len(npcs) > 0 {
+		parts = append(parts, g.tr(player, "здесь: %s", strings.Join(g.localList(player, npcs), ", ")))
+	}
+	if note := g.noteFor(room, player); note != "" {
+		parts = append(parts, g.local(player, note))
+	}
+	return g.withExits(strings.Join(parts, ", "), room, player)
+}
+
+// enterText - что видит игрок, войдя в комнату
+func (g *Game) enterText(player *Player) string {
+	room := player.room
+	if room.enter == "" {
+		return g.describe(room, player)
+	}
+	return g.withExits(g.local(player, room.enter), room, player)
+}
+
+// itemsText перечисляет предметы по местам в том порядке, в котором они лежат:
+// "на столе: ключи, конспекты, на стуле: рюкзак"
+func (g *Game) itemsText(room *Room, player *Player) string {
+	var places []string
+	byPlace := make(map[string][]string)
+	for _, item := range room.Items {
+		place := room.placeOf(item)
+		if place == defaultPlace {
+			place = g.tr(player, defaultPlace)
+		} else {
+			place = g.local(player, place)
+		}
+		if _, ok := byPlace[place]; !ok {
+			places = append(places, place)
+		}
+		byPlace[place] = append(byPlace[place], g.local(player, item))
+	}
+	groups := make([]string, 0, len(places))
+	for _, place := range places {
+		groups = append(groups, place+": "+strings.Join(byPlace[place], ", "))
+	}
+	return strings.Join(groups, ", ")
+}
+
+func (room *Room) placeOf(item string) string {
+	if place, ok := room.Places[item]; ok {
+		return place
+	}
+	if room.place != "" {
+		return room.place
+	}
+	return defaultPlace
+}
+
+// noteFor - первая приписка, условие которой выполняется для игрока
+func (g *Game) noteFor(room *Room, player *Player) string {
+	for i := range room.notes {
+		if g.check(room.notes[i].If, player) {
+			return room.notes[i].Text
+		}
+	}
+	return ""
+}
+
+func (g *Game) withExits(text string, room *Room, player *Player) string {
+	exits := room.exits()
+	if len(exits) == 0 {
+		return text
+	}
+	return g.tr(player, "%s. можно пройти - %s", text, strings.Join(g.localList(player, exits), ", "))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDescribe_Composition(t *testing.T) {
+	data := `{
+		"start": "кабинет",
+		"items": [{"name": "сумка", "capacity": 10, "wear": "плечо"}],
+		"rooms": [
+			{"name": "кабинет", "description": "тихо", "empty": "пусто", "place": "на подоконнике",
+				"items": ["лампа", "книга", "ручка", "кружка", "сумка"],
+				"places": {"лампа": "на столе", "книга": "на полке", "ручка": "на столе", "сумка": "на полке"},
+				"notes": [
+					{"if": {"flag": "свет"}, "text": "горит лампа"},
+					{"if": {"has": "лампа"}, "text": "лампа у тебя"}
+				],
+				"objects": [
+					{"name": "выключатель", "actions": [{"type": "use", "item": "лампа", "commentary": "щелк", "effects": [{"setFlag": "свет"}]}]},
+					{"name": "дверь", "exit": "коридор", "actions": [{"type": "go", "to": "коридор"}]},
+					{"name": "окно", "actions": [{"type": "go", "to": "двор", "commentary": "ты вылез в окно"}]}
+				]},
+			{"name": "коридор", "enter": "ты в коридоре", "items": []},
+			{"name": "двор", "items": []}
+		]
+	}`
+	w, err := ParseWorld([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	room := g.rooms["кабинет"]
+
+	want := "тихо, на столе: лампа, ручка, на полке: книга, сумка, на подоконнике: кружка. можно пройти - коридор, окно"
+	if got := g.describe(room, g.player); got != want {
+		t.Errorf("expected %q\n\tgot %q", want, got)
+	}
+	g.handleCommand("взять сумка")
+	want = "тихо, на столе: лампа, ручка, на полке: книга, на подоконнике: кружка. можно пройти - коридор, окно"
+	if got := g.describe(room, g.player); got != want {
+		t.Errorf("expected %q\n\tgot %q", want, got)
+	}
+
+	playSteps(t, g, []gameCase{
+		{1, "взять лампа", "предмет добавлен в инвентарь: лампа"},
+		{2, "осмотреться", "тихо, на полке: книга, на столе: ручка, на подоконнике: кружка, лампа у тебя. можно пройти - коридор, окно"},
+		{3, "применить лампа выключатель", "щелк"},
+		{4, "осмотреться", "тихо, на полке: книга, на столе: ручка, на подоконнике: кружка, горит лампа. можно пройти - коридор, окно"},
+		{5, "взять ручка", "предмет добавлен в инвентарь: ручка"},
+		{6, "взять книга", "предмет добавлен в инвентарь: книга"},
+		{7, "взять кружка", "предмет добавлен в инвентарь: кружка"},
+		{8, "осмотреться", "тихо, пусто, горит лампа. можно пройти - коридор, окно"},
+		{9, "идти коридор", "ты в коридоре"},
+	})
+
+	g.player.room = room
+	playSteps(t, g, []gameCase{
+		{1, "идти окно", "ты вылез в окно"},
+		{2, "осмотреться", ""},
+	})
+}
+
+func TestDescribe_PerPlayerNotes(t *testing.T) {
+	g := newSharedGame(t, "Kate", "Tristan")
+	kate := g.players["Kate"]
+	kate.Items = []string{"рюкзак", "ключи", "конспекты"}
+
+	want := "ты находишься на кухне, на столе: чай, надо идти в универ. можно пройти - коридор. Кроме вас тут ещё Tristan"
+	if got := g.HandleCommand("Kate", "осмотреться"); got[0].Text != want {
+		t.Errorf("expected %q\n\tgot %q", want, got[0].Text)
+	}
+	want = "ты находишься на кухне, на столе: чай, надо собрать рюкзак и идти в универ. можно пройти - коридор. Кроме вас тут ещё Kate"
+	if got := g.HandleCommand("Tristan", "осмотреться"); got[0].Text != want {
+		t.Errorf("expected %q\n\tgot %q", want, got[0].Text)
+	}
+}
+
+func TestDescribe_ValidatesPlaces(t *testing.T) {
+	data := `{"start": "к", "rooms": [{"name": "к", "items": [], "places": {"ключи": "на столе"},
+		"notes": [{"if": {"has": "телефон"}, "text": "звонит"}]}]}`
+	_, err := ParseWorld([]byte(data))
+	werrs, ok := err.(WorldErrors)
+	if !ok || len(werrs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+// EventKind - вид события движка
+type EventKind string
+
+const (
+	// ItemTaken - игрок взял предмет из комнаты или достал из контейнера
+	ItemTaken EventKind = "ItemTaken"
+	// RoomEntered - игрок перешел в другую комнату
+	RoomEntered EventKind = "RoomEntered"
+	// ObjectUsed - игрок применил предмет к объекту и действие сработало
+	ObjectUsed EventKind = "ObjectUsed"
+	// CommandRejected - игра не поняла команду или не смогла ее выполнить:
+	// взять, положить, пройти, поговорить и т.д. В Reason - ответ игроку
+	CommandRejected EventKind = "CommandRejected"
+)
+
+// Event - что произошло в игре. Player пустой для игрока одиночной игры,
+// остальные поля заполняются, если имеют смысл для вида события
+type Event struct {
+	Kind   EventKind
+	Player string
+	// Room - где все произошло, для RoomEntered - куда игрок пришел, From - откуда
+	Room string
+	From string
+	// Item - взятый или примененный предмет, Container - откуда предмет достали
+	Item      string
+	Container string
+	Object    string
+	// Command и Reason - отклоненная команда и почему
+	Command Command
+	Reason  string
+}
+
+// Handler - подписчик на события. Вызывается после того, как команда выполнена
+// и игра отпущена, так что из него можно обращаться к игре. Команды разных
+// игроков общего мира выполняются параллельно, поэтому подписчик должен
+// выдерживать вызовы из нескольких горутин
+type Handler func(Event)
+
+type subscriber struct {
+	id      int
+	kinds   map[EventKind]bool
+	handler Handler
+}
+
+// Subscribe подписывает обработчик на события перечисленных видов, без видов -
+// на все события. Возвращает функцию, которая отменяет подписку
+func (g *Game) Subscribe(h Handler, kinds ...EventKind) func() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastSubscriber++
+	s := subscriber{id: g.lastSubscriber, handler: h}
+	if len(kinds) > 0 {
+		s.kinds = make(map[EventKind]bool, len(kinds))
+		for _, kind := range kinds {
+			s.kinds[kind] = true
+		}
+	}
+	g.subscribers = append(g.subscribers, s)
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		for i, other := range g.subscribers {
+			if other.id == s.id {
+				g.subscribers = append(g.subscribers[:i:i], g.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit ставит событие в очередь текущей команды. Без подписчиков события не копятся
+func (g *Game) emit(player *Player, e Event) {
+	if len(g.subscribers) == 0 {
+		return
+	}
+	e.Player = player.Name
+	if e.Room == "" {
+		e.Room = player.room.Name
+	}
+	g.events = append(g.events, e)
+}
+
+// reject - команда не выполнена: событие для подписчиков и ответ игроку
+func (g *Game) reject(player *Player, cmd Command, reason string) string {
+	g.emit(player, Event{Kind: CommandRejected, Command: cmd, Reason: reason})
+	return reason
+}
+
+// takeEvents забирает накопленные события вместе с подписчиками, которым
+// их нужно доставить. Вызывается под блокировкой игры
+func (g *Game) takeEvents() ([]Event, []subscriber) {
+	events := g.events
+	g.events = nil
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return events, append([]subscriber(nil), g.subscribers...)
+}
+
+// publish доставляет события подписчикам. Вызывается без блокировки игры
+func publish(events []Event, subscribers []subscriber) {
+	for _, e := range events {
+		for _, s := range subscribers {
+			if s.kinds == nil || s.kinds[e.Kind] {
+				s.handler(e)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvents_CourseGame(t *testing.T) {
+	g := NewGame(testWorld(t))
+	var got []Event
+	g.Subscribe(func(e Event) { got = append(got, e) })
+
+	for _, command := range []string{
+		"идти комната", "идти коридор", "идти комната", "взять рюкзак", "взять ключи",
+		"идти коридор", "применить ключи дверь", "применить ключи шкаф", "завтракать",
+	} {
+		g.handleCommand(command)
+	}
+
+	want := []Event{
+		{Kind: CommandRejected, Room: "кухня", Command: Command{Verb: "go", Object: "комната"}, Reason: "нет пути в комната"},
+		{Kind: RoomEntered, Room: "коридор", From: "кухня"},
+		{Kind: RoomEntered, Room: "комната", From: "коридор"},
+		{Kind: ItemTaken, Room: "комната", Item: "рюкзак"},
+		{Kind: ItemTaken, Room: "комната", Item: "ключи"},
+		{Kind: RoomEntered, Room: "коридор", From: "комната"},
+		{Kind: ObjectUsed, Room: "коридор", Object: "дверь", Item: "ключи"},
+		{Kind: CommandRejected, Room: "коридор", Command: Command{Verb: "use", Object: "ключи", Target: "шкаф"}, Reason: "не к чему применить"},
+		{Kind: CommandRejected, Room: "коридор", Reason: "неизвестная команда"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected events\n\t%+v\n\tgot\n\t%+v", want, got)
+	}
+}
+
+func TestEvents_FilterAndUnsubscribe(t *testing.T) {
+	g := NewGame(testWorld(t))
+	var rooms []string
+	stop := g.Subscribe(func(e Event) { rooms = append(rooms, e.Room) }, RoomEntered)
+
+	g.handleCommand("идти коридор")
+	g.handleCommand("взять рюкзак")
+	// подписчик вызывается без блокировки и может обращаться к игре
+	g.Subscribe(func(e Event) { g.State() })
+	g.handleCommand("идти комната")
+	stop()
+	g.handleCommand("идти коридор")
+
+	if want := []string{"коридор", "комната"}; !reflect.DeepEqual(rooms, want) {
+		t.Errorf("expected %v got %v", want, rooms)
+	}
+}
+
+func TestEvents_SharedWorld(t *testing.T) {
+	g := newSharedGame(t, "Kate", "Tristan")
+	var got []Event
+	g.Subscribe(func(e Event) { got = append(got, e) }, RoomEntered)
+
+	g.HandleCommand("Tristan", "идти коридор")
+	g.HandleCommand("Kate", "сказать привет")
+
+	want := []Event{{Kind: RoomEntered, Player: "Tristan", Room: "коридор", From: "кухня"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v got %+v", want, got)
+	}
+}
+
+func TestEvents_RejectedCommands(t *testing.T) {
+	g := NewGame(testWorld(t))
+	var got []Event
+	g.Subscribe(func(e Event) { got = append(got, e) }, CommandRejected)
+
+	for _, command := range []string{
+		"положить ключи", "соединить", "осмотреть телефон", "поговорить", "осмотреться",
+	} {
+		g.handleCommand(command)
+	}
+
+	want := []Event{
+		{Kind: CommandRejected, Room: "кухня", Command: Command{Verb: "put", Object: "ключи"}, Reason: "нет предмета в инвентаре - ключи"},
+		{Kind: CommandRejected, Room: "кухня", Command: Command{Verb: "combine"}, Reason: "укажите, что с чем соединить"},
+		{Kind: CommandRejected, Room: "кухня", Command: Command{Verb: "examine", Object: "телефон"}, Reason: "тут нет такого"},
+		{Kind: CommandRejected, Room: "кухня", Command: Command{Verb: "talk"}, Reason: "укажите, с кем поговорить"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected events\n\t%+v\n\tgot\n\t%+v", want, got)
+	}
+}
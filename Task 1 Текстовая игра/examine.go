@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+)
+
+// examine - команда "осмотреть": персонаж, объект или выход комнаты,
+// предмет у игрока или на виду в комнате
+func (g *Game) examine(player *Player, name string) (bool, string) {
+	room := player.room
+	if _, ok := room.npcIn(name); ok {
+		return true, g.examineNPC(player, name)
+	}
+	if obj, ok := getObject(room, name); ok {
+		return true, g.examineObject(player, obj)
+	}
+	if player.hasItem(name) {
+		return true, g.examineItem(player, &player.Inventory, name)
+	}
+	if room.hasItem(name) {
+		return true, g.examineItem(player, &room.Inventory, name)
+	}
+	return false, g.tr(player, "тут нет такого")
+}
+
+// examineObject описывает объект первым подходящим действием look. Эффекты
+// описания срабатывают при осмотре - так находятся спрятанные предметы.
+// Выход без описаний сообщает, можно ли сейчас пройти, объект с состоянием -
+// открыт ли он
+func (g *Game) examineObject(player *Player, obj *GameObject) string {
+	if response, ok := g.handleObjectAction(player, obj, ActionLook, ""); ok && response != "" {
+		return response
+	}
+	for _, action := range obj.Actions {
+		if action.action != ActionGo {
+			continue
+		}
+		if !action.allows(obj.State) || !g.check(action.when, player) {
+			if action.failure != "" {
+				return g.local(player, action.failure)
+			}
+			return g.tr(player, "путь закрыт")
+		}
+		return g.tr(player, "можно пройти - %s", g.local(player, destination(action)))
+	}
+	if obj.State != "" {
+		return g.stateText(player, obj)
+	}
+	return g.tr(player, "ничего особенного")
+}
+
+// destination - комната, в которую ведет переход
+func destination(action Action) string {
+	for i := len(action.effects) - 1; i >= 0; i-- {
+		if action.effects[i].Move != "" {
+			return action.effects[i].Move
+		}
+	}
+	return ""
+}
+
+// examineItem описывает предмет из inv, а у контейнера перечисляет, что внутри
+func (g *Game) examineItem(player *Player, inv *Inventory, item string) string {
+	def := g.world.item(item)
+	var parts []string
+	if def.Description != "" {
+		parts = append(parts, g.local(player, def.Description))
+	}
+	if def.Capacity > 0 {
+		if contents := inv.Contents[item]; len(contents) > 0 {
+			parts = append(parts, g.tr(player, "внутри: %s", strings.Join(g.localList(player, contents), ", ")))
+		} else {
+			parts = append(parts, g.tr(player, "внутри пусто"))
+		}
+	}
+	if len(parts) == 0 {
+		return g.tr(player, "ничего особенного")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reveal выкладывает спрятанный предмет к остальным предметам комнаты
+func (room *Room) reveal(item string) {
+	for i, hidden := range room.hidden {
+		if hidden == item {
+			room.hidden = append(room.hidden[:i:i], room.hidden[i+1:]...)
+			room.Items = append(room.Items, item)
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// под столом спрятана монета, ее находит только осмотр стола
+const studyWorld = `{
+	"start": "кабинет",
+	"items": [
+		{"name": "сумка", "capacity": 2, "wear": "плечо", "description": "холщовая сумка"},
+		{"name": "монета", "weight": 1, "description": "старая монета"}
+	],
+	"rooms": [
+		{"name": "кабинет", "description": "пыльный кабинет", "items": ["сумка"], "hidden": ["монета"],
+			"places": {"сумка": "на кресле", "монета": "под столом"}, "objects": [
+			{"name": "стол", "actions": [
+				{"type": "look", "if": {"not": {"flag": "стол осмотрен"}}, "commentary": "под столом что-то блестит",
+					"effects": [{"reveal": "монета"}, {"setFlag": "стол осмотрен"}]},
+				{"type": "look", "commentary": "массивный дубовый стол"}
+			]},
+			{"name": "окно", "actions": []},
+			{"name": "дверь", "exit": "коридор", "actions": [
+				{"type": "use", "item": "монета", "commentary": "замок щелкнул", "effects": [{"setFlag": "открыто"}]},
+				{"type": "go", "to": "коридор", "if": {"flag": "открыто"}, "fail": "дверь заперта"}
+			]}
+		]},
+		{"name": "коридор", "description": "темный коридор", "items": []}
+	]
+}`
+
+func TestExamine_ObjectsItemsAndExits(t *testing.T) {
+	w, err := ParseWorld([]byte(studyWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	playSteps(t, g, []gameCase{
+		{1, "осмотреться", "пыльный кабинет, на кресле: сумка. можно пройти - коридор"},
+		{2, "взять монету", "нет такого"},
+		{3, "осмотреть окно", "ничего особенного"},
+		{4, "осмотреть дверь", "дверь заперта"},
+		{5, "осмотреть сумку", "холщовая сумка, внутри пусто"},
+		{6, "осмотреть стол", "под столом что-то блестит"},
+		{7, "осмотреться", "пыльный кабинет, на кресле: сумка, под столом: монета. можно пройти - коридор"},
+		{8, "осмотреть стол", "массивный дубовый стол"},
+		{9, "осмотреть монету", "старая монета"},
+		{10, "взять сумку", "вы надели: сумка"},
+		{11, "взять монету", "предмет добавлен в инвентарь: монета"},
+		{12, "осмотреть сумку", "холщовая сумка, внутри: монета"},
+		{13, "применить монету к двери", "замок щелкнул"},
+		{14, "осмотреть коридор", "можно пройти - коридор"},
+		{15, "осмотреть лампу", "тут нет такого"},
+	})
+}
+
+func TestExamine_RevealIsSavedAndUndone(t *testing.T) {
+	w, err := ParseWorld([]byte(studyWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	g.handleCommand("осмотреть стол")
+	s := g.Snapshot()
+	if len(s.Rooms[0].Hidden) != 0 || !reflect.DeepEqual(s.Rooms[0].Items, []string{"сумка", "монета"}) {
+		t.Errorf("coin must be revealed: %+v", s.Rooms[0])
+	}
+
+	if got := g.handleCommand("отменить"); got != "отменено: осмотреть стол" {
+		t.Errorf("unexpected undo reply %q", got)
+	}
+	if got := g.handleCommand("взять монету"); got != "нет такого" {
+		t.Errorf("coin must be hidden again, got %q", got)
+	}
+
+	restored := NewGame(w)
+	if err := restored.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.handleCommand("осмотреть монету"); got != "старая монета" {
+		t.Errorf("restored game lost revealed coin: %q", got)
+	}
+}
+
+func TestExamine_CheckerFindsHiddenItems(t *testing.T) {
+	w, err := ParseWorld([]byte(studyWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := Check(w)
+	if len(report.UnreachableItems) != 0 || len(report.UnreachableRooms) != 0 {
+		t.Errorf("everything must be reachable: %s", report)
+	}
+}
+
+func TestExamine_DefaultWorld(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "осмотреть чай", "кружка горячего чая"},
+		{2, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{3, "осмотреть дверь", "входная дверь, заперта на ключ"},
+		{4, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{5, "надеть рюкзак", "вы надели: рюкзак"},
+		{6, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{7, "осмотреть рюкзак", "старый походный рюкзак, внутри: ключи"},
+		{8, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{9, "применить ключи дверь", "дверь открыта"},
+		{10, "осмотреть улицу", "дверь открыта, за ней улица"},
+		{11, "language en", "language: English"},
+		{12, "examine door", "the door is open, the street is behind it"},
+		{13, "look at the backpack", "an old hiking backpack, inside: keys"},
+	})
+}
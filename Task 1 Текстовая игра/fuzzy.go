@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// levenshtein - сколько букв нужно вставить, удалить или заменить, чтобы из a получить b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// typoLimit - сколько опечаток прощается в слове такой длины. В коротких словах
+// одна опечатка превращает слово в другое, поэтому там не прощается ни одной
+func typoLimit(word string) int {
+	switch n := utf8.RuneCountInString(word); {
+	case n < 4:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// similar подбирает кандидатов, похожих на слово: с опечатками в пределах
+// typoLimit или начинающихся с него. Возвращаются только самые близкие,
+// без повторов, в порядке кандидатов
+func similar(word string, candidates []string, key func(string) string) []string {
+	best := -1
+	var result []string
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		distance := levenshtein(key(word), key(c))
+		if utf8.RuneCountInString(word) >= 3 && strings.HasPrefix(normalize(c), normalize(word)) {
+			distance = 0
+		}
+		if distance > typoLimit(word) && distance != 0 {
+			continue
+		}
+		switch {
+		case best < 0 || distance < best:
+			best = distance
+			result = []string{c}
+			seen = map[string]bool{c: true}
+		case distance == best:
+			result = append(result, c)
+			seen[c] = true
+		}
+	}
+	return result
+}
+
+// stemPhrase - основы всех слов имени через пробел
+func (loc *Locale) stemPhrase(phrase string) string {
+	words := strings.Fields(phrase)
+	for i, w := range words {
+		words[i] = loc.stem(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// guessName ищет имя, на которое похожа фраза с опечаткой. Если похожих
+// несколько, возвращает их все, чтобы предложить игроку выбор
+func (loc *Locale) guessName(phrase string, known []string) (string, []string) {
+	found := similar(phrase, known, loc.stemPhrase)
+	if len(found) == 1 {
+		return found[0], nil
+	}
+	return "", found
+}
+
+// guessVerb ищет глагол, на который похоже слово. Глаголы одного действия
+// ("взять", "возьми") не считаются разными вариантами
+func (loc *Locale) guessVerb(word string) (string, []string) {
+	verbs := make([]string, 0, len(loc.Verbs))
+	for verb := range loc.Verbs {
+		if !strings.Contains(verb, " ") {
+			verbs = append(verbs, verb)
+		}
+	}
+	sort.Strings(verbs)
+	found := similar(word, verbs, normalize)
+	actions := make(map[string]bool)
+	for _, verb := range found {
+		actions[loc.Verbs[verb]] = true
+	}
+	if len(actions) == 1 {
+		return loc.Verbs[found[0]], nil
+	}
+	return "", found
+}
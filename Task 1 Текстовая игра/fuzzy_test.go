@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"ключи", "ключи", 0},
+		{"клюси", "ключи", 1},
+		{"коридр", "коридор", 1},
+		{"улица", "", 5},
+		{"дверь", "верфь", 2},
+	}
+	for _, c := range cases {
+		if got := levenshtein(c.a, c.b); got != c.want {
+			t.Errorf("levenshtein(%q, %q): expected %d got %d", c.a, c.b, c.want, got)
+		}
+	}
+}
+
+func TestGuess(t *testing.T) {
+	known := []string{"ключи", "ключник", "коридор", "конспекты", "кухня", "чай"}
+	cases := []struct {
+		phrase  string
+		name    string
+		similar []string
+	}{
+		{"коридр", "коридор", nil},
+		{"конспкты", "конспекты", nil},
+		{"кух", "кухня", nil},
+		{"клю", "", []string{"ключи", "ключник"}},
+		{"час", "", nil},
+		{"комната", "", nil},
+	}
+	for _, c := range cases {
+		name, found := russian.guessName(c.phrase, known)
+		if name != c.name || !reflect.DeepEqual(found, c.similar) {
+			t.Errorf("%q: expected %q %v got %q %v", c.phrase, c.name, c.similar, name, found)
+		}
+	}
+
+	if verb, _ := russian.guessVerb("взьять"); verb != "take" {
+		t.Errorf("expected take, got %q", verb)
+	}
+	if verb, found := russian.guessVerb("завтракать"); verb != "" || len(found) != 0 {
+		t.Errorf("expected nothing, got %q %v", verb, found)
+	}
+}
+
+func TestGame_Typos(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "осмотртеться", "ты находишься на кухне, на столе: чай, надо собрать рюкзак и идти в универ. можно пройти - коридор"},
+		{2, "иди в корридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{3, "идти ком", "ты в своей комнате. можно пройти - коридор"},
+		{4, "надеть рюкзк", "вы надели: рюкзак"},
+		{5, "взять к", "нет такого"},
+		{6, "взять конспекты", "предмет добавлен в инвентарь: конспекты"},
+		{7, "по", "неизвестная команда"},
+		{8, "вы", "неизвестная команда"},
+		{9, "выб ключи", "нет предмета в инвентаре - ключи"},
+		{10, "пол ключи", "неизвестная команда, возможно, вы имели в виду: положи, положить, получи, получить"},
+	})
+}
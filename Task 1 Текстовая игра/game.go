@@ -0,0 +1,579 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// actionsAliases - глаголы команд в неопределенной форме и в повелительном наклонении
+var actionsAliases = map[string]string{
+	"поднять":        "take",
+	"подними":        "take",
+	"надеть":         "take",
+	"надень":         "take",
+	"получить":       "take",
+	"получи":         "take",
+	"взять":          "take",
+	"возьми":         "take",
+	"бери":           "take",
+	"забрать":        "take",
+	"забери":         "take",
+	"осмотреться":    "look",
+	"осмотрись":      "look",
+	"посмотреть":     "look",
+	"посмотри":       "look",
+	"оглядеться":     "look",
+	"оглядись":       "look",
+	"идти":           "go",
+	"иди":            "go",
+	"пойти":          "go",
+	"пойди":          "go",
+	"пойдем":         "go",
+	"ступай":         "go",
+	"применить":      "use",
+	"примени":        "use",
+	"использовать":   "use",
+	"используй":      "use",
+	"положить":       "put",
+	"положи":         "put",
+	"убрать":         "put",
+	"убери":          "put",
+	"достать":        "takeout",
+	"достань":        "takeout",
+	"вынуть":         "takeout",
+	"вынь":           "takeout",
+	"выбросить":      "drop",
+	"выбрось":        "drop",
+	"бросить":        "drop",
+	"брось":          "drop",
+	"инвентарь":      "inventory",
+	"выйти из игры":  "exit",
+	"выйди из игры":  "exit",
+	"сохранить":      "save",
+	"сохрани":        "save",
+	"загрузить":      "load",
+	"загрузи":        "load",
+	"сказать":        "say",
+	"скажи":          "say",
+	"сказать_игроку": "whisper",
+	"шепнуть":        "whisper",
+	"шепни":          "whisper",
+	"отдать":         "give",
+	"отдай":          "give",
+	"передать":       "give",
+	"передай":        "give",
+	"отменить":       "undo",
+	"отмени":         "undo",
+	"повторить":      "redo",
+	"повтори":        "redo",
+	"история":        "history",
+	"цели":           "goals",
+	"поговорить":     "talk",
+	"поговори":       "talk",
+	"заговорить":     "talk",
+	"заговори":       "talk",
+	"ответить":       "answer",
+	"ответь":         "answer",
+	"осмотреть":      "examine",
+	"осмотри":        "examine",
+	"время":          "time",
+	"открыть":        "open",
+	"открой":         "open",
+	"закрыть":        "close",
+	"закрой":         "close",
+	"запереть":       "lock",
+	"запри":          "lock",
+	"сломать":        "break",
+	"сломай":         "break",
+	"отпереть":       "open",
+	"отопри":         "open",
+	"соединить":      "combine",
+	"соедини":        "combine",
+	"смешать":        "combine",
+	"смешай":         "combine",
+	"карта":          "map",
+	"карту":          "map",
+	"выходы":         "exits",
+	"язык":           "locale",
+	"language":       "locale",
+}
+
+type ActionType int
+
+const (
+	ActionTake ActionType = iota
+	ActionLook
+	ActionGo
+	ActionUse
+	// смена состояния объекта: открыть, закрыть, запереть, сломать
+	ActionOpen
+	ActionClose
+	ActionLock
+	ActionBreak
+)
+
+// Action - действие с объектом. Условие и эффекты - данные из описания мира,
+// поэтому действия можно сохранять и проверять без запуска игры
+type Action struct {
+	action ActionType
+	// item - предмет, который нужно применить (для ActionUse), а для смены
+	// состояния - инструмент, который должен быть у игрока
+	item string
+	// from - в каких состояниях объекта действие доступно, пустой - в любых
+	from            []string
+	when            *Condition
+	effects         []Effect
+	afterCommentary string
+	// failure - ответ, если условие не выполнено
+	failure string
+}
+
+type GameObject struct {
+	Name string
+	Exit string
+	// State - заперт, закрыт, открыт или сломан, пустой у объектов без состояния
+	State   string
+	Actions []Action
+	// описание, из которого собран объект, - по нему объект сохраняется и восстанавливается
+	def ObjectDef
+}
+
+type Room struct {
+	Inventory
+	Name        string
+	Reactions   map[string]string
+	Description string
+	Objects     map[string]GameObject
+	Flags       map[string]bool
+	// Places - где в комнате лежит предмет ("на столе")
+	Places map[string]string
+	// hidden - спрятанные предметы, которые еще не нашли
+	hidden []string
+	// из описания мира: текст при входе, текст для пустой комнаты и условные приписки
+	enter string
+	empty string
+	place string
+	notes []Note
+	// персонажи, которые находятся в комнате
+	npcs []NPCDef
+	// порядок объектов, в котором они описаны в мире
+	objectOrder []string
+}
+
+type Player struct {
+	Inventory
+	Name string
+	// комната, в которой сейчас находится игрок
+	room *Room
+	// выполненные цели и проваленная цель, после которой игра проиграна
+	goals  map[string]bool
+	failed string
+	// dialogue - разговор с персонажем, который сейчас ведет игрок
+	dialogue *Dialogue
+	// visited - комнаты, в которых игрок побывал, для карты
+	visited map[string]bool
+}
+
+// Game - отдельная игровая сессия: собственная копия мира и игроки в нем.
+// Несколько игр в одном процессе друг с другом не пересекаются,
+// а экспортируемые методы и handleCommand можно вызывать из разных горутин.
+type Game struct {
+	mu    sync.Mutex
+	world *World
+	rooms map[string]*Room
+	// player - игрок одиночной игры, с ним работает handleCommand
+	player *Player
+	// именованные игроки общего мира, order - порядок их входа
+	players map[string]*Player
+	order   []string
+	// счетчики, которые меняют эффекты действий
+	counters map[string]int
+	// turn - сколько ходов прошло, timers - отложенные эффекты
+	turn   int
+	timers []Timer
+	// сообщения другим игрокам, накопленные за текущую команду
+	outbox []Message
+	// выполненные и отмененные команды одиночной игры
+	history []historyEntry
+	undone  []historyEntry
+	// подписчики на события и события текущей команды, которые им предстоит доставить
+	subscribers    []subscriber
+	lastSubscriber int
+	events         []Event
+	// locales - выбранные языки игроков по именам, пустое имя - игрок одиночной игры
+	locales map[string]*Locale
+	// SaveDir - каталог для команд сохранить/загрузить, пустой - команды недоступны
+	SaveDir string
+}
+
+// NewGame собирает новую игру по описанию мира
+func NewGame(w *World) *Game {
+	g := &Game{world: w, players: make(map[string]*Player), counters: make(map[string]int), locales: make(map[string]*Locale)}
+	var start *Room
+	g.rooms, start = w.build()
+	g.player = &Player{
+		Inventory: Inventory{Items: []string{}},
+		room:      start,
+	}
+	g.player.visit(start)
+	return g
+}
+
+// handleCommand выполняет команду игрока и возвращает ответ игры
+func (g *Game) handleCommand(command string) string {
+	var reply string
+	g.turnLocked(func() {
+		reply = g.resolveReaction(command, g.player)
+	})
+	return reply
+}
+
+// turnLocked выполняет ход под блокировкой игры и уже без нее рассылает его
+// события подписчикам. Блокировка снимается, даже если ход упал с паникой
+func (g *Game) turnLocked(turn func()) {
+	var events []Event
+	var subscribers []subscriber
+	func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		turn()
+		events, subscribers = g.takeEvents()
+	}()
+	publish(events, subscribers)
+}
+
+// Функция для поиска объекта, в том числе по имени выхода
+func getObject(room *Room, name string) (*GameObject, bool) {
+	if obj, ok := room.Objects[name]; ok {
+		return &obj, true
+	}
+	for _, obj := range room.Objects {
+		if obj.Exit == name {
+			return &obj, true
+		}
+	}
+	return nil, false
+}
+
+// Универсальная обработка действий с объектами
+func (g *Game) handleObjectAction(player *Player, obj *GameObject, actionType ActionType, itemToUse string) (string, bool) {
+	var blocked *Action
+	for _, action := range obj.Actions {
+		if action.action != actionType {
+			continue
+		}
+		// Для ActionUse подходит только действие с тем же предметом
+		if actionType == ActionUse && action.item != itemToUse {
+			continue
+		}
+		// действие доступно не в любом состоянии: запертую дверь не открыть рукой,
+		// а пройти можно только через открытую
+		if !action.allows(obj.State) {
+			blocked = &action
+			continue
+		}
+		missing := action.item != "" && actionType != ActionUse && !player.hasItem(action.item)
+		if missing || !g.check(action.when, player) {
+			// описаний объекта может быть несколько, подходит первое с выполненным условием
+			if actionType == ActionLook {
+				continue
+			}
+			if action.failure != "" {
+				return g.local(player, action.failure), false
+			}
+			if missing {
+				return g.tr(player, "нужен предмет - %s", g.local(player, action.item)), false
+			}
+			if actionType == ActionGo {
+				return g.tr(player, "путь закрыт"), false
+			}
+			return g.tr(player, "ничего не произошло"), false
+		}
+		g.apply(action.effects, player)
+		if actionType == ActionUse {
+			g.emit(player, Event{Kind: ObjectUsed, Object: obj.Name, Item: itemToUse})
+		}
+		return g.local(player, action.afterCommentary), true
+	}
+	if blocked != nil && actionType != ActionLook {
+		if actionType == ActionGo && blocked.failure != "" {
+			return g.local(player, blocked.failure), false
+		}
+		return g.stateText(player, obj), false
+	}
+	return "", false
+}
+
+func (g *Game) resolveReaction(msg string, player *Player) string {
+	if strings.TrimSpace(msg) == "" {
+		return g.tr(player, "Введите команду")
+	}
+	// игрок называет предметы и объекты на своем языке, а игра знает их по именам из мира
+	known := g.knownNames(player)
+	shown := g.localList(player, known)
+	cmd := g.localeOf(player).parseCommand(msg, shown)
+	cmd.Object = original(cmd.Object, shown, known)
+	cmd.Target = original(cmd.Target, shown, known)
+	// во время разговора достаточно назвать номер ответа
+	if _, err := strconv.Atoi(strings.TrimSpace(msg)); err == nil && player.dialogue != nil {
+		cmd = Command{Verb: "answer", Object: strings.TrimSpace(msg)}
+	}
+	switch cmd.Verb {
+	case "undo":
+		return g.undo(player)
+	case "redo":
+		return g.redo(player)
+	case "history":
+		return g.historyText(player)
+	case "locale":
+		return g.setLocale(player, cmd.Text)
+	}
+	if player != g.player {
+		return g.act(cmd, player)
+	}
+	return g.record(strings.TrimSpace(msg), func() string {
+		return g.act(cmd, player)
+	})
+}
+
+// act - ход игрока: команда, события хода и проверка целей
+func (g *Game) act(cmd Command, player *Player) string {
+	single := player == g.player
+	reply := g.execute(cmd, player)
+	// загрузка сохранения заменяет игрока одиночной игры, ход продолжается уже за нового
+	if single {
+		player = g.player
+	}
+	// нераспознанная команда не отнимает хода
+	if cmd.Verb != "" && len(cmd.Suggestions) == 0 {
+		events := g.tick(player)
+		reply = withEvents(reply, g.localList(player, events))
+		// в общем мире о событиях узнают все игроки
+		for _, name := range g.order {
+			if other := g.players[name]; other != player && len(events) > 0 {
+				g.notify(other, strings.Join(g.localList(other, events), ". "))
+			}
+		}
+	}
+	g.updateGoals(player)
+	return reply
+}
+
+// execute выполняет разобранную команду
+func (g *Game) execute(cmd Command, player *Player) string {
+	room := player.room
+
+	if len(cmd.Suggestions) > 0 {
+		hint := g.tr(player, "возможно, вы имели в виду: %s", strings.Join(cmd.Suggestions, ", "))
+		if cmd.Verb == "" {
+			return g.reject(player, cmd, g.tr(player, "неизвестная команда, %s", hint))
+		}
+		return g.reject(player, cmd, hint)
+	}
+
+	switch cmd.Verb {
+	case "take":
+		if cmd.Object == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите предмет"))
+		}
+		// "взять ключи из рюкзака" - это достать из контейнера, "со стола" - просто взять
+		var ok bool
+		var response string
+		if cmd.Target != "" && g.world.item(cmd.Target).Capacity > 0 {
+			ok, response = g.takeOut(player, cmd.Object, cmd.Target)
+		} else {
+			ok, response = g.pickItem(player, cmd.Object)
+		}
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "look":
+		return g.lookAround(player)
+
+	case "go":
+		if cmd.Object == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите направление"))
+		}
+		obj, ok := getObject(room, cmd.Object)
+		if !ok {
+			return g.reject(player, cmd, g.tr(player, "нет пути в %s", g.local(player, cmd.Object)))
+		}
+
+		response, success := g.handleObjectAction(player, obj, ActionGo, "")
+		if !success {
+			return g.reject(player, cmd, response)
+		}
+		if response == "" {
+			return g.enterText(player)
+		}
+		return response
+
+	case "use":
+		if cmd.Object == "" || cmd.Target == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите предмет и объект"))
+		}
+
+		// Проверяем, есть ли предмет у игрока
+		if !player.hasItem(cmd.Object) {
+			return g.reject(player, cmd, g.tr(player, "нет предмета в инвентаре - %s", g.local(player, cmd.Object)))
+		}
+
+		obj, ok := getObject(room, cmd.Target)
+		if !ok {
+			return g.reject(player, cmd, g.tr(player, "не к чему применить"))
+		}
+
+		response, success := g.handleObjectAction(player, obj, ActionUse, cmd.Object)
+		if success {
+			return response
+		}
+		if response == "" {
+			response = g.tr(player, "не к чему применить")
+		}
+		return g.reject(player, cmd, response)
+
+	case "put":
+		if cmd.Object == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите, что и куда положить"))
+		}
+		// без контейнера предмет кладется в комнату
+		var ok bool
+		var response string
+		if cmd.Target == "" {
+			ok, response = g.dropItem(player, cmd.Object)
+		} else {
+			ok, response = g.putItem(player, cmd.Object, cmd.Target)
+		}
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "takeout":
+		if cmd.Object == "" || cmd.Target == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите, что и откуда достать"))
+		}
+		ok, response := g.takeOut(player, cmd.Object, cmd.Target)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "drop":
+		if cmd.Object == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите предмет"))
+		}
+		ok, response := g.dropItem(player, cmd.Object)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "inventory":
+		return g.inventoryText(player)
+
+	case "goals":
+		return g.goalsText(player)
+
+	case "time":
+		return g.timeText(player)
+
+	case "combine":
+		if cmd.Object == "" || cmd.Target == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите, что с чем соединить"))
+		}
+		return g.combine(player, cmd)
+
+	case "open", "close", "lock", "break":
+		if cmd.Object == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите объект"))
+		}
+		return g.changeState(player, cmd)
+
+	case "map":
+		return g.mapText(player)
+
+	case "exits":
+		return g.exitsText(player)
+
+	case "examine":
+		if cmd.Object == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите, что осмотреть"))
+		}
+		ok, response := g.examine(player, cmd.Object)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "talk":
+		if cmd.Object == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите, с кем поговорить"))
+		}
+		ok, response := g.talk(player, cmd.Object)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "answer":
+		ok, response := g.answer(player, cmd.Object)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "say":
+		ok, response := g.say(player, cmd.Text)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "whisper":
+		if cmd.Target == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите игрока"))
+		}
+		ok, response := g.whisper(player, cmd.Target, cmd.Text)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "give":
+		if cmd.Object == "" || cmd.Target == "" {
+			return g.reject(player, cmd, g.tr(player, "укажите предмет и игрока"))
+		}
+		ok, response := g.give(player, cmd.Object, cmd.Target)
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "save", "load":
+		name := cmd.Text
+		if name == "" {
+			name = "autosave"
+		}
+		var ok bool
+		var response string
+		if cmd.Verb == "save" {
+			ok, response = g.saveCommand(player, name)
+		} else {
+			ok, response = g.loadCommand(player, name)
+		}
+		if !ok {
+			return g.reject(player, cmd, response)
+		}
+		return response
+
+	case "exit":
+		return g.tr(player, "Спасибо за игру!")
+
+	default:
+		return g.reject(player, cmd, g.tr(player, "неизвестная команда"))
+	}
+}
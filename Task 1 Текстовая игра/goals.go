@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+)
+
+// Goal - цель квеста. Цель выполнена, как только выполнилось условие Done,
+// и провалена (вместе со всей игрой), если раньше выполнилось условие Fail
+type Goal struct {
+	Name   string     `json:"name"`
+	Done   *Condition `json:"done"`
+	Fail   *Condition `json:"fail,omitempty"`
+	Points int        `json:"points,omitempty"`
+	// Optional - цель нужна только ради очков, для победы ее выполнять не обязательно
+	Optional bool `json:"optional,omitempty"`
+}
+
+// updateGoals отмечает цели, которые игрок выполнил или провалил последней командой.
+// Когда игра окончена, цели больше не меняются
+func (g *Game) updateGoals(player *Player) {
+	if _, over := g.outcome(player); over {
+		return
+	}
+	for _, goal := range g.world.Goals {
+		if player.goals[goal.Name] {
+			continue
+		}
+		if g.check(goal.Done, player) {
+			if player.goals == nil {
+				player.goals = make(map[string]bool)
+			}
+			player.goals[goal.Name] = true
+			continue
+		}
+		if goal.Fail != nil && g.check(goal.Fail, player) {
+			player.failed = goal.Name
+			return
+		}
+	}
+}
+
+// score - очки игрока и сколько всего можно набрать
+func (g *Game) score(player *Player) (int, int) {
+	got, total := 0, 0
+	for _, goal := range g.world.Goals {
+		total += goal.Points
+		if player.goals[goal.Name] {
+			got += goal.Points
+		}
+	}
+	return got, total
+}
+
+// outcome - итог игры, если она окончена: все обязательные цели выполнены или одна провалена
+func (g *Game) outcome(player *Player) (string, bool) {
+	got, total := g.score(player)
+	if player.failed != "" {
+		return g.tr(player, "поражение: провалена цель - %s, очки: %d из %d", g.local(player, player.failed), got, total), true
+	}
+	required := 0
+	for _, goal := range g.world.Goals {
+		if goal.Optional {
+			continue
+		}
+		if !player.goals[goal.Name] {
+			return "", false
+		}
+		required++
+	}
+	if required == 0 {
+		return "", false
+	}
+	return g.tr(player, "победа! все цели выполнены, очки: %d из %d", got, total), true
+}
+
+// goalsText - команда "цели": что уже выполнено и сколько набрано очков
+func (g *Game) goalsText(player *Player) string {
+	if len(g.world.Goals) == 0 {
+		return g.tr(player, "в этом мире нет целей")
+	}
+	lines := make([]string, 0, len(g.world.Goals))
+	for _, goal := range g.world.Goals {
+		status := g.tr(player, "не выполнено")
+		switch {
+		case player.goals[goal.Name]:
+			status = g.tr(player, "выполнено")
+		case player.failed == goal.Name:
+			status = g.tr(player, "провалено")
+		}
+		if goal.Optional {
+			status = g.tr(player, "%s, необязательно", status)
+		}
+		lines = append(lines, g.local(player, goal.Name)+" - "+status)
+	}
+	got, total := g.score(player)
+	return g.tr(player, "цели: %s. очки: %d из %d", strings.Join(lines, ", "), got, total)
+}
+
+// Result - итог одиночной игры и окончена ли она
+func (g *Game) Result() (string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.outcome(g.player)
+}
+
+// PlayerResult - итог игры для игрока общего мира
+func (g *Game) PlayerResult(name string) (string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.players[name]
+	if !ok {
+		return "", false
+	}
+	return g.outcome(p)
+}
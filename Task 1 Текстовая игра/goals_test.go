@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const bridgeWorld = `{
+	"start": "берег",
+	"items": [{"name": "карман", "capacity": 1, "wear": "пояс"}, {"name": "монета", "weight": 1}],
+	"goals": [
+		{"name": "найти монету", "points": 5, "optional": true, "done": {"has": "монета"}},
+		{"name": "перейти мост", "points": 10, "done": {"at": "другой берег"}, "fail": {"at": "река"}}
+	],
+	"rooms": [
+		{"name": "берег", "items": ["карман", "монета"], "objects": [
+			{"name": "мост", "actions": [{"type": "go", "to": "другой берег"}]},
+			{"name": "брод", "actions": [{"type": "go", "to": "река"}]}
+		]},
+		{"name": "другой берег", "description": "ты на другом берегу", "items": []},
+		{"name": "река", "description": "тебя унесло течением", "items": []}
+	]
+}`
+
+func TestGoals_DefaultWorld(t *testing.T) {
+	g := NewGame(testWorld(t))
+	want := "цели: собрать рюкзак - не выполнено, выйти на улицу - не выполнено, взять чай с собой - не выполнено, необязательно. очки: 0 из 35"
+	if got := g.handleCommand("цели"); got != want {
+		t.Errorf("expected %q\n\tgot %q", want, got)
+	}
+
+	playSteps(t, g, gameCases[0][:7])
+	if _, over := g.Result(); over {
+		t.Fatal("game finished too early")
+	}
+	want = "цели: собрать рюкзак - выполнено, выйти на улицу - не выполнено, взять чай с собой - не выполнено, необязательно. очки: 10 из 35"
+	if got := g.handleCommand("цели"); got != want {
+		t.Errorf("expected %q\n\tgot %q", want, got)
+	}
+
+	// ответы на команды не меняются, итог игры сообщают фронтенды
+	playSteps(t, g, gameCases[0][7:])
+	summary, over := g.Result()
+	if !over || summary != "победа! все цели выполнены, очки: 30 из 35" {
+		t.Errorf("unexpected result %q %v", summary, over)
+	}
+	if state := g.State(); !state.Finished || state.Score != 30 || state.Summary != summary {
+		t.Errorf("unexpected state %+v", state)
+	}
+}
+
+func TestGoals_Fail(t *testing.T) {
+	w, err := ParseWorld([]byte(bridgeWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	g.handleCommand("надеть карман")
+	g.handleCommand("взять монета")
+	g.handleCommand("идти брод")
+	summary, over := g.Result()
+	if !over || summary != "поражение: провалена цель - перейти мост, очки: 5 из 15" {
+		t.Errorf("unexpected result %q %v", summary, over)
+	}
+	want := "цели: найти монету - выполнено, необязательно, перейти мост - провалено. очки: 5 из 15"
+	if got := g.handleCommand("цели"); got != want {
+		t.Errorf("expected %q\n\tgot %q", want, got)
+	}
+
+	// итог сохраняется и отменяется вместе с игрой
+	restored := NewGame(w)
+	if err := restored.Restore(g.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := restored.Result(); got != summary {
+		t.Errorf("restored result: expected %q got %q", summary, got)
+	}
+	g.handleCommand("отменить")
+	g.handleCommand("идти мост")
+	if summary, _ := g.Result(); summary != "победа! все цели выполнены, очки: 15 из 15" {
+		t.Errorf("after undo: unexpected result %q", summary)
+	}
+}
+
+func TestGoals_Validation(t *testing.T) {
+	data := `{
+		"start": "берег",
+		"goals": [
+			{"name": "цель", "done": {"at": "луна"}},
+			{"name": "цель", "points": -1, "done": {"has": "монета"}},
+			{"name": "без условия"}
+		],
+		"rooms": [{"name": "берег", "items": ["монета"]}]
+	}`
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		`цель "цель": неизвестная комната "луна"`,
+		`цель "цель" описана несколько раз`,
+		`цель "цель": очки не могут быть отрицательными`,
+		`цель "без условия": не указано, когда она выполнена`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q not reported:\n%v", want, err)
+		}
+	}
+}
+
+func TestServer_EndsSessionWithSummary(t *testing.T) {
+	w, err := ParseWorld([]byte(bridgeWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := startServer(t, &Server{World: w})
+
+	c := dial(t, addr)
+	c.expect(greeting)
+	c.send("идти мост")
+	c.expect("ты на другом берегу")
+	c.expect("победа! все цели выполнены, очки: 10 из 15")
+	c.expectClosed()
+}
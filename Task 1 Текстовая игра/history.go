@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// historyLimit - сколько последних команд помнит игра
+const historyLimit = 100
+
+// historyEntry - выполненная команда и состояние игры до и после нее
+type historyEntry struct {
+	command string
+	before  Snapshot
+	after   Snapshot
+	// changed - команда что-то изменила в мире, только такие команды отменяются
+	changed bool
+}
+
+// record выполняет команду одиночной игры и запоминает, что она изменила.
+// Новая команда, изменившая мир, делает отмененные команды неповторяемыми
+func (g *Game) record(command string, run func() string) string {
+	before := g.snapshot()
+	reply := run()
+	after := g.snapshot()
+	entry := historyEntry{command: command, before: before, after: after, changed: !sameWorld(before, after)}
+	if entry.changed {
+		g.undone = nil
+	}
+	g.history = append(g.history, entry)
+	if len(g.history) > historyLimit {
+		g.history = g.history[len(g.history)-historyLimit:]
+	}
+	return reply
+}
+
+// sameWorld сравнивает состояния без учета часов: ход идет от любой команды,
+// а отменять стоит только то, что изменило мир
+func sameWorld(a, b Snapshot) bool {
+	a.Turn, b.Turn = 0, 0
+	return reflect.DeepEqual(a, b)
+}
+
+// undo возвращает мир в состояние до последней команды, которая его изменила.
+// Команды, которые ничего не меняли, отбрасываются вместе с ней
+func (g *Game) undo(player *Player) string {
+	if player != g.player {
+		return g.tr(player, "в общей игре отмена недоступна")
+	}
+	for i := len(g.history) - 1; i >= 0; i-- {
+		entry := g.history[i]
+		if !entry.changed {
+			continue
+		}
+		g.restore(entry.before)
+		g.history = g.history[:i]
+		g.undone = append(g.undone, entry)
+		return g.tr(player, "отменено: %s", entry.command)
+	}
+	return g.tr(player, "нечего отменять")
+}
+
+// redo повторяет последнюю отмененную команду
+func (g *Game) redo(player *Player) string {
+	if player != g.player {
+		return g.tr(player, "в общей игре отмена недоступна")
+	}
+	if len(g.undone) == 0 {
+		return g.tr(player, "нечего повторять")
+	}
+	entry := g.undone[len(g.undone)-1]
+	g.undone = g.undone[:len(g.undone)-1]
+	g.restore(entry.after)
+	g.history = append(g.history, entry)
+	return g.tr(player, "повторено: %s", entry.command)
+}
+
+// historyText перечисляет выполненные команды по порядку
+func (g *Game) historyText(player *Player) string {
+	if player != g.player {
+		return g.tr(player, "в общей игре история недоступна")
+	}
+	if len(g.history) == 0 {
+		return g.tr(player, "история пуста")
+	}
+	lines := make([]string, 0, len(g.history))
+	for i, entry := range g.history {
+		lines = append(lines, strconv.Itoa(i+1)+". "+entry.command)
+	}
+	return strings.Join(lines, ", ")
+}
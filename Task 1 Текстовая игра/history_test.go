@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistory_UndoRedo(t *testing.T) {
+	g := NewGame(testWorld(t))
+	start := g.Snapshot()
+	playSteps(t, g, []gameCase{
+		{1, "отменить", "нечего отменять"},
+		{2, "повторить", "нечего повторять"},
+		{3, "история", "история пуста"},
+		{4, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{5, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{6, "надеть рюкзак", "вы надели: рюкзак"},
+		{7, "осмотреться", "на столе: ключи, конспекты. можно пройти - коридор"},
+		{8, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{9, "история", "1. идти коридор, 2. идти комната, 3. надеть рюкзак, 4. осмотреться, 5. взять ключи"},
+		{10, "отменить", "отменено: взять ключи"},
+		{11, "инвентарь", "надето: рюкзак"},
+		// осмотреться и инвентарь ничего не меняли - они отбрасываются вместе с рюкзаком
+		{12, "отменить", "отменено: надеть рюкзак"},
+		{13, "история", "1. идти коридор, 2. идти комната"},
+		{14, "повторить", "повторено: надеть рюкзак"},
+		{15, "повторить", "повторено: взять ключи"},
+		{16, "повторить", "нечего повторять"},
+		{17, "инвентарь", "надето: рюкзак, рюкзак: ключи"},
+		{18, "отменить", "отменено: взять ключи"},
+		// новая команда, изменившая мир, забывает отмененные
+		{19, "взять конспекты", "предмет добавлен в инвентарь: конспекты"},
+		{20, "повторить", "нечего повторять"},
+	})
+
+	for i := 0; i < 4; i++ {
+		g.handleCommand("отменить")
+	}
+	if got := g.handleCommand("отменить"); got != "нечего отменять" {
+		t.Errorf("expected history to be exhausted, got %q", got)
+	}
+	if !reflect.DeepEqual(start, g.Snapshot()) {
+		t.Errorf("undo did not return to the start:\n%+v\n%+v", start, g.Snapshot())
+	}
+}
+
+func TestHistory_UndoDoor(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, gameCases[0][:9])
+	playSteps(t, g, []gameCase{
+		{1, "отменить", "отменено: применить ключи дверь"},
+		{2, "идти улица", "дверь закрыта"},
+		{3, "повторить", "повторено: применить ключи дверь"},
+		{4, "идти улица", "на улице весна. можно пройти - домой"},
+	})
+}
+
+func TestHistory_Limit(t *testing.T) {
+	g := NewGame(testWorld(t))
+	for i := 0; i < historyLimit+10; i++ {
+		g.handleCommand("осмотреться")
+	}
+	if len(g.history) != historyLimit {
+		t.Errorf("expected %d entries, got %d", historyLimit, len(g.history))
+	}
+}
+
+func TestHistory_SharedGame(t *testing.T) {
+	g := newSharedGame(t, "Kate")
+	msgs := g.HandleCommand("Kate", "отменить")
+	if len(msgs) != 1 || msgs[0].Text != "в общей игре отмена недоступна" {
+		t.Errorf("unexpected reply %v", msgs)
+	}
+}
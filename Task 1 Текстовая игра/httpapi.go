@@ -0,0 +1,286 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// apiSessionTTL и apiMaxSessions - ограничения API по умолчанию
+	apiSessionTTL  = time.Hour
+	apiMaxSessions = 1000
+	// apiMaxBody - самое большое тело запроса, с запасом на сохранение игры
+	apiMaxBody = 1 << 20
+)
+
+// API - HTTP/JSON-интерфейс к игре. Каждая сессия - отдельная игра:
+//
+//	POST   /sessions                 создать сессию, можно с языком {"locale": "en"}
+//	GET    /sessions/{id}            состояние сессии
+//	POST   /sessions/{id}/commands   выполнить команду {"command": "..."}
+//	GET    /sessions/{id}/snapshot   полное состояние игры для сохранения
+//	PUT    /sessions/{id}/snapshot   восстановить игру из сохраненного состояния
+//	DELETE /sessions/{id}            завершить сессию
+//
+// Сессии, к которым долго не обращались, забываются, а число сессий ограничено
+type API struct {
+	// SessionTTL - через сколько бездействия сессия забывается, 0 - никогда
+	SessionTTL time.Duration
+	// MaxSessions - сколько сессий может быть одновременно, 0 - без ограничений
+	MaxSessions int
+
+	world *World
+
+	mu       sync.Mutex
+	sessions map[string]*apiSession
+	// now - текущее время, в тестах его подменяют
+	now func() time.Time
+}
+
+// apiSession - игра одной сессии
+type apiSession struct {
+	game     *Game
+	lastSeen time.Time
+}
+
+func NewAPI(w *World) *API {
+	return &API{SessionTTL: apiSessionTTL, MaxSessions: apiMaxSessions, world: w, sessions: make(map[string]*apiSession)}
+}
+
+type createRequest struct {
+	Locale string `json:"locale"`
+}
+
+type commandRequest struct {
+	Command string `json:"command"`
+}
+
+type sessionResponse struct {
+	ID    string `json:"id"`
+	Reply string `json:"reply,omitempty"`
+	State State  `json:"state"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, apiMaxBody)
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "sessions" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		api.createSession(w, r)
+
+	case len(parts) == 2:
+		switch r.Method {
+		case http.MethodGet:
+			api.getSession(w, parts[1])
+		case http.MethodDelete:
+			api.deleteSession(w, parts[1])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+	case parts[2] == "commands":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		api.runCommand(w, r, parts[1])
+
+	case parts[2] == "snapshot":
+		switch r.Method {
+		case http.MethodGet:
+			api.getSnapshot(w, parts[1])
+		case http.MethodPut:
+			api.putSnapshot(w, r, parts[1])
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (api *API) createSession(w http.ResponseWriter, r *http.Request) {
+	// тело необязательно: без него сессия на языке по умолчанию
+	var req createRequest
+	if !decodeBody(w, r, &req, true) {
+		return
+	}
+	g := NewGame(api.world)
+	if req.Locale != "" {
+		if err := g.SetLocale(req.Locale); err != nil {
+			writeError(w, http.StatusBadRequest, "unknown locale")
+			return
+		}
+	}
+	id, err := newSessionID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "cannot create session")
+		return
+	}
+
+	api.mu.Lock()
+	api.expire()
+	if api.MaxSessions > 0 && len(api.sessions) >= api.MaxSessions {
+		api.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "too many sessions")
+		return
+	}
+	api.sessions[id] = &apiSession{game: g, lastSeen: api.clock()}
+	api.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, sessionResponse{ID: id, State: g.State()})
+}
+
+func (api *API) getSession(w http.ResponseWriter, id string) {
+	g, ok := api.session(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, sessionResponse{ID: id, State: g.State()})
+}
+
+func (api *API) deleteSession(w http.ResponseWriter, id string) {
+	api.mu.Lock()
+	_, ok := api.sessions[id]
+	delete(api.sessions, id)
+	api.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *API) runCommand(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := api.session(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	var req commandRequest
+	if !decodeBody(w, r, &req, false) {
+		return
+	}
+	if strings.TrimSpace(req.Command) == "" {
+		writeError(w, http.StatusBadRequest, "command is empty")
+		return
+	}
+	reply, state := g.Play(req.Command)
+	writeJSON(w, http.StatusOK, sessionResponse{ID: id, Reply: reply, State: state})
+}
+
+func (api *API) getSnapshot(w http.ResponseWriter, id string) {
+	g, ok := api.session(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, g.Snapshot())
+}
+
+func (api *API) putSnapshot(w http.ResponseWriter, r *http.Request, id string) {
+	g, ok := api.session(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	var s Snapshot
+	if !decodeBody(w, r, &s, false) {
+		return
+	}
+	if err := g.Restore(s); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, sessionResponse{ID: id, State: g.State()})
+}
+
+// session находит игру сессии и отмечает, что к ней обращались
+func (api *API) session(id string) (*Game, bool) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.expire()
+	s, ok := api.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	s.lastSeen = api.clock()
+	return s.game, true
+}
+
+// expire забывает сессии, простоявшие дольше SessionTTL. Вызывается под api.mu
+func (api *API) expire() {
+	if api.SessionTTL <= 0 {
+		return
+	}
+	now := api.clock()
+	for id, s := range api.sessions {
+		if now.Sub(s.lastSeen) > api.SessionTTL {
+			delete(api.sessions, id)
+		}
+	}
+}
+
+func (api *API) clock() time.Time {
+	if api.now != nil {
+		return api.now()
+	}
+	return time.Now()
+}
+
+// decodeBody разбирает JSON из тела запроса, а при ошибке сам отвечает клиенту.
+// optional - тело можно не присылать
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}, optional bool) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil, optional && err == io.EOF:
+		return true
+	case errors.As(err, &tooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+	default:
+		writeError(w, http.StatusBadRequest, "bad json: "+err.Error())
+	}
+	return false
+}
+
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
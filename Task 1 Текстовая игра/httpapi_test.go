@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func doJSON(t *testing.T, h http.Handler, method, path, body string) (int, sessionResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	var resp sessionResponse
+	if rr.Code < 300 && rr.Body.Len() > 0 {
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal %s: %v", rr.Body.String(), err)
+		}
+	}
+	return rr.Code, resp
+}
+
+func TestAPI_Session(t *testing.T) {
+	api := NewAPI(testWorld(t))
+
+	code, created := doJSON(t, api, "POST", "/sessions", "")
+	if code != http.StatusCreated || created.ID == "" {
+		t.Fatalf("create: code=%d resp=%+v", code, created)
+	}
+	want := State{Room: "кухня", Inventory: []string{}, Exits: []string{"коридор"}}
+	if !reflect.DeepEqual(created.State, want) {
+		t.Errorf("initial state: expected %+v got %+v", want, created.State)
+	}
+
+	commands := "/sessions/" + created.ID + "/commands"
+	steps := []struct {
+		command string
+		reply   string
+		state   State
+	}{
+		{"идти коридор", "ничего интересного. можно пройти - кухня, комната, улица",
+			State{Room: "коридор", Inventory: []string{}, Exits: []string{"кухня", "комната", "улица"}}},
+		{"идти комната", "ты в своей комнате. можно пройти - коридор",
+			State{Room: "комната", Inventory: []string{}, Exits: []string{"коридор"}}},
+		{"надеть рюкзак", "вы надели: рюкзак",
+			State{Room: "комната", Inventory: []string{"рюкзак"}, Exits: []string{"коридор"}}},
+		{"взять ключи", "предмет добавлен в инвентарь: ключи",
+			State{Room: "комната", Inventory: []string{"рюкзак", "ключи"}, Exits: []string{"коридор"}}},
+	}
+	for _, step := range steps {
+		code, resp := doJSON(t, api, "POST", commands, `{"command": "`+step.command+`"}`)
+		if code != http.StatusOK {
+			t.Fatalf("%q: code %d", step.command, code)
+		}
+		if resp.Reply != step.reply {
+			t.Errorf("%q: expected reply %q got %q", step.command, step.reply, resp.Reply)
+		}
+		if !reflect.DeepEqual(resp.State, step.state) {
+			t.Errorf("%q: expected state %+v got %+v", step.command, step.state, resp.State)
+		}
+	}
+
+	code, got := doJSON(t, api, "GET", "/sessions/"+created.ID, "")
+	if code != http.StatusOK || got.State.Room != "комната" {
+		t.Errorf("get: code=%d resp=%+v", code, got)
+	}
+
+	// другая сессия живет своей жизнью
+	_, other := doJSON(t, api, "POST", "/sessions", "")
+	if other.ID == created.ID || other.State.Room != "кухня" {
+		t.Errorf("sessions are not independent: %+v", other)
+	}
+
+	if code, _ := doJSON(t, api, "DELETE", "/sessions/"+created.ID, ""); code != http.StatusNoContent {
+		t.Errorf("delete: code %d", code)
+	}
+	if code, _ := doJSON(t, api, "GET", "/sessions/"+created.ID, ""); code != http.StatusNotFound {
+		t.Errorf("get after delete: code %d", code)
+	}
+}
+
+func TestAPI_Errors(t *testing.T) {
+	api := NewAPI(testWorld(t))
+	_, created := doJSON(t, api, "POST", "/sessions", "")
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{"unknown path", "GET", "/games", "", http.StatusNotFound},
+		{"list sessions", "GET", "/sessions", "", http.StatusMethodNotAllowed},
+		{"unknown session", "POST", "/sessions/nope/commands", `{"command": "осмотреться"}`, http.StatusNotFound},
+		{"bad json", "POST", "/sessions/" + created.ID + "/commands", `{`, http.StatusBadRequest},
+		{"empty command", "POST", "/sessions/" + created.ID + "/commands", `{"command": " "}`, http.StatusBadRequest},
+		{"get commands", "GET", "/sessions/" + created.ID + "/commands", "", http.StatusMethodNotAllowed},
+		{"delete unknown", "DELETE", "/sessions/nope", "", http.StatusNotFound},
+		{"too deep", "GET", "/sessions/" + created.ID + "/commands/1", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+			rr := httptest.NewRecorder()
+			api.ServeHTTP(rr, req)
+			if rr.Code != c.code {
+				t.Fatalf("expected %d got %d body=%s", c.code, rr.Code, rr.Body.String())
+			}
+			var e errorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &e); err != nil || e.Error == "" {
+				t.Errorf("expected JSON error, got %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPI_Limits(t *testing.T) {
+	api := NewAPI(testWorld(t))
+	api.MaxSessions = 2
+	api.SessionTTL = 10 * time.Minute
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	api.now = func() time.Time { return now }
+
+	_, first := doJSON(t, api, "POST", "/sessions", "")
+	now = now.Add(5 * time.Minute)
+	_, second := doJSON(t, api, "POST", "/sessions", "")
+	if code, _ := doJSON(t, api, "POST", "/sessions", ""); code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d over the limit, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	// первая сессия простояла дольше SessionTTL и забыта, место освободилось
+	now = now.Add(6 * time.Minute)
+	if code, _ := doJSON(t, api, "GET", "/sessions/"+first.ID, ""); code != http.StatusNotFound {
+		t.Errorf("idle session must expire, got %d", code)
+	}
+	if code, _ := doJSON(t, api, "GET", "/sessions/"+second.ID, ""); code != http.StatusOK {
+		t.Errorf("active session must stay, got %d", code)
+	}
+	if code, _ := doJSON(t, api, "POST", "/sessions", ""); code != http.StatusCreated {
+		t.Errorf("expected a free slot, got %d", code)
+	}
+
+	body := `{"command": "` + strings.Repeat("а", apiMaxBody) + `"}`
+	if code, _ := doJSON(t, api, "POST", "/sessions/"+second.ID+"/commands", body); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected %d for a huge body, got %d", http.StatusRequestEntityTooLarge, code)
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// ItemDef - свойства предмета. Предметы, которых нет в каталоге мира,
+// ничего не весят, ничего не вмещают и не надеваются
+type ItemDef struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight,omitempty"`
+	// Capacity - сколько веса вмещает предмет. Предмет с Capacity > 0 - контейнер
+	Capacity int `json:"capacity,omitempty"`
+	// Wear - слот, в который предмет надевается ("спина")
+	Wear string `json:"wear,omitempty"`
+	// Fixed - предмет нельзя унести (шкаф)
+	Fixed bool `json:"fixed,omitempty"`
+	// Description - что игрок видит, осмотрев предмет
+	Description string `json:"description,omitempty"`
+}
+
+// Inventory - предметы игрока или комнаты. Items - то, что лежит (или надето)
+// сверху, Contents - что лежит внутри контейнеров, в том числе вложенных.
+// Имена предметов в мире уникальны, поэтому контейнер определяется по имени
+type Inventory struct {
+	Items    []string
+	Contents map[string][]string
+}
+
+// проверяем есть ли такой предмет в инвентаре, в том числе внутри контейнеров
+func (inv *Inventory) hasItem(item string) bool {
+	_, ok := inv.containerOf(item)
+	return ok
+}
+
+// containerOf - в каком контейнере лежит предмет, "" - если сверху
+func (inv *Inventory) containerOf(item string) (string, bool) {
+	for _, invItem := range inv.Items {
+		if invItem == item {
+			return "", true
+		}
+	}
+	for container, items := range inv.Contents {
+		for _, invItem := range items {
+			if invItem == item {
+				return container, true
+			}
+		}
+	}
+	return "", false
+}
+
+// allItems - все предметы инвентаря: каждый контейнер и следом его содержимое
+func (inv *Inventory) allItems() []string {
+	result := []string{}
+	var walk func(items []string)
+	walk = func(items []string) {
+		for _, item := range items {
+			result = append(result, item)
+			walk(inv.Contents[item])
+		}
+	}
+	walk(inv.Items)
+	return result
+}
+
+// detach вынимает предмет из инвентаря вместе со всем, что в нем лежит
+func (inv *Inventory) detach(item string) map[string][]string {
+	container, ok := inv.containerOf(item)
+	if !ok {
+		return nil
+	}
+	if container == "" {
+		inv.Items = removeString(inv.Items, item)
+	} else {
+		inv.Contents[container] = removeString(inv.Contents[container], item)
+		if len(inv.Contents[container]) == 0 {
+			delete(inv.Contents, container)
+		}
+	}
+	nested := make(map[string][]string)
+	var walk func(name string)
+	walk = func(name string) {
+		items, ok := inv.Contents[name]
+		if !ok {
+			return
+		}
+		nested[name] = items
+		delete(inv.Contents, name)
+		for _, it := range items {
+			walk(it)
+		}
+	}
+	walk(item)
+	return nested
+}
+
+// attach кладет предмет с содержимым в контейнер, а при container == "" - сверху
+func (inv *Inventory) attach(item, container string, nested map[string][]string) {
+	if container == "" {
+		inv.Items = append(inv.Items, item)
+	} else {
+		if inv.Contents == nil {
+			inv.Contents = make(map[string][]string)
+		}
+		inv.Contents[container] = append(inv.Contents[container], item)
+	}
+	for name, items := range nested {
+		if inv.Contents == nil {
+			inv.Contents = make(map[string][]string)
+		}
+		inv.Contents[name] = items
+	}
+}
+
+func (inv *Inventory) removeItem(item string) {
+	inv.detach(item)
+}
+
+func removeString(items []string, item string) []string {
+	for i, it := range items {
+		if it == item {
+			return append(items[:i:i], items[i+1:]...)
+		}
+	}
+	return items
+}
+
+// item - свойства предмета из каталога мира
+func (w *World) item(name string) ItemDef {
+	for _, def := range w.Items {
+		if def.Name == name {
+			return def
+		}
+	}
+	return ItemDef{Name: name}
+}
+
+// weight - вес предмета вместе со всем, что в нем лежит
+func (g *Game) weight(item string, contents map[string][]string) int {
+	total := g.world.item(item).Weight
+	for _, it := range contents[item] {
+		total += g.weight(it, contents)
+	}
+	return total
+}
+
+// freeSpace - сколько еще веса поместится в контейнер
+func (g *Game) freeSpace(inv *Inventory, container string) int {
+	free := g.world.item(container).Capacity
+	for _, it := range inv.Contents[container] {
+		free -= g.weight(it, inv.Contents)
+	}
+	return free
+}
+
+// placeFor подбирает, куда положить предмет: надеть, если он надевается и слот
+// свободен, иначе убрать в первый контейнер с достаточным запасом места.
+// Контейнер except (откуда предмет достают) не рассматривается
+func (g *Game) placeFor(inv *Inventory, item string, weight int, except string) (string, bool) {
+	if slot := g.world.item(item).Wear; slot != "" {
+		free := true
+		for _, worn := range inv.Items {
+			if g.world.item(worn).Wear == slot {
+				free = false
+				break
+			}
+		}
+		if free {
+			return "", true
+		}
+	}
+	for _, container := range inv.allItems() {
+		if container == except || container == item || g.world.item(container).Capacity == 0 ||
+			isInside(inv, container, item) {
+			continue
+		}
+		if g.freeSpace(inv, container) >= weight {
+			return container, true
+		}
+	}
+	return "", false
+}
+
+// hasContainers - есть ли у игрока хоть один контейнер
+func (g *Game) hasContainers(inv *Inventory) bool {
+	for _, item := range inv.allItems() {
+		if g.world.item(item).Capacity > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stow перекладывает предмет из инвентаря from игроку: надевает или убирает в контейнер
+func (g *Game) stow(player *Player, from *Inventory, item, except string) (string, bool) {
+	weight := g.weight(item, from.Contents)
+	container, ok := g.placeFor(&player.Inventory, item, weight, except)
+	if !ok {
+		if g.hasContainers(&player.Inventory) {
+			return g.tr(player, "не хватает места"), false
+		}
+		return g.tr(player, "некуда класть"), false
+	}
+	player.attach(item, container, from.detach(item))
+	if container == "" && g.world.item(item).Wear != "" {
+		return g.tr(player, "вы надели: %s", g.local(player, item)), true
+	}
+	return g.tr(player, "предмет добавлен в инвентарь: %s", g.local(player, item)), true
+}
+
+// pickItem - взять предмет из комнаты
+func (g *Game) pickItem(player *Player, item string) (bool, string) {
+	room := player.room
+	if container, ok := room.containerOf(item); !ok || container != "" {
+		return false, g.tr(player, "нет такого")
+	}
+	if g.world.item(item).Fixed {
+		return false, g.tr(player, "это не унести")
+	}
+	response, ok := g.stow(player, &room.Inventory, item, "")
+	if ok {
+		g.emit(player, Event{Kind: ItemTaken, Item: item})
+	}
+	return ok, response
+}
+
+// containerFor ищет контейнер по имени сначала у игрока, потом в комнате
+func (g *Game) containerFor(player *Player, name string) (*Inventory, string) {
+	var inv *Inventory
+	switch {
+	case player.hasItem(name):
+		inv = &player.Inventory
+	case player.room.hasItem(name):
+		inv = &player.room.Inventory
+	default:
+		return nil, g.tr(player, "нет такого")
+	}
+	if g.world.item(name).Capacity == 0 {
+		return nil, g.tr(player, "это не контейнер - %s", g.local(player, name))
+	}
+	return inv, ""
+}
+
+// putItem - положить предмет из инвентаря в контейнер (свой или в комнате)
+func (g *Game) putItem(player *Player, item, container string) (bool, string) {
+	if !player.hasItem(item) {
+		return false, g.tr(player, "нет предмета в инвентаре - %s", g.local(player, item))
+	}
+	target, problem := g.containerFor(player, container)
+	if problem != "" {
+		return false, problem
+	}
+	if item == container || isInside(&player.Inventory, container, item) {
+		return false, g.tr(player, "нельзя положить предмет в самого себя")
+	}
+	if current, _ := player.containerOf(item); current == container && target == &player.Inventory {
+		return false, g.tr(player, "%s уже внутри (%s)", g.local(player, item), g.local(player, container))
+	}
+	if g.freeSpace(target, container) < g.weight(item, player.Contents) {
+		return false, g.tr(player, "не хватает места")
+	}
+	target.attach(item, container, player.detach(item))
+	return true, g.tr(player, "вы положили %s (%s)", g.local(player, item), g.local(player, container))
+}
+
+// takeOut - достать предмет из контейнера (своего или в комнате) и забрать себе
+func (g *Game) takeOut(player *Player, item, container string) (bool, string) {
+	source, problem := g.containerFor(player, container)
+	if problem != "" {
+		return false, problem
+	}
+	if current, ok := source.containerOf(item); !ok || current != container {
+		return false, g.tr(player, "нет предмета - %s (%s)", g.local(player, item), g.local(player, container))
+	}
+	if source == &player.Inventory {
+		// из своего контейнера предмет перекладывается, а если некуда - берется в руки
+		place, _ := g.placeFor(source, item, g.weight(item, source.Contents), container)
+		player.attach(item, place, player.detach(item))
+	} else if response, ok := g.stow(player, source, item, container); !ok {
+		return false, response
+	}
+	g.emit(player, Event{Kind: ItemTaken, Item: item, Container: container})
+	return true, g.tr(player, "вы достали %s (%s)", g.local(player, item), g.local(player, container))
+}
+
+// inventoryText перечисляет инвентарь игрока: надетое, что в руках
+// и содержимое каждого контейнера - "надето: рюкзак, рюкзак: ключи, конспекты".
+// Контейнер назван без предлога, чтобы его имя не приходилось склонять
+func (g *Game) inventoryText(player *Player) string {
+	var worn, held []string
+	for _, item := range player.Items {
+		if g.world.item(item).Wear != "" {
+			worn = append(worn, item)
+		} else {
+			held = append(held, item)
+		}
+	}
+	var groups []string
+	if len(worn) > 0 {
+		groups = append(groups, g.tr(player, "надето: %s", strings.Join(g.localList(player, worn), ", ")))
+	}
+	if len(held) > 0 {
+		groups = append(groups, g.tr(player, "в руках: %s", strings.Join(g.localList(player, held), ", ")))
+	}
+	for _, container := range player.allItems() {
+		if items := player.Contents[container]; len(items) > 0 {
+			groups = append(groups, g.local(player, container)+": "+strings.Join(g.localList(player, items), ", "))
+		}
+	}
+	if len(groups) == 0 {
+		return g.tr(player, "инвентарь пуст")
+	}
+	return strings.Join(groups, ", ")
+}
+
+// dropItem - выложить предмет из инвентаря в комнату вместе с его содержимым.
+// Выброшенный предмет лежит на месте по умолчанию, а не там, где его взяли
+func (g *Game) dropItem(player *Player, item string) (bool, string) {
+	if !player.hasItem(item) {
+		return false, g.tr(player, "нет предмета в инвентаре - %s", g.local(player, item))
+	}
+	room := player.room
+	room.attach(item, "", player.detach(item))
+	delete(room.Places, item)
+	return true, g.tr(player, "предмет оставлен в комнате: %s", g.local(player, item))
+}
+
+// unheld - контейнеры из contents, до которых не добраться от предметов items:
+// ничьи или лежащие сами в себе. Их содержимое нигде не лежит, а вес такого
+// контейнера посчитать нельзя
+func unheld(items []string, contents map[string][]string) []string {
+	reached := make(map[string]bool)
+	var walk func(items []string)
+	walk = func(items []string) {
+		for _, item := range items {
+			if reached[item] {
+				continue
+			}
+			reached[item] = true
+			walk(contents[item])
+		}
+	}
+	walk(items)
+	var result []string
+	for container := range contents {
+		if !reached[container] {
+			result = append(result, container)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isInside проверяет, лежит ли item (на любой глубине) внутри container
+func isInside(inv *Inventory, item, container string) bool {
+	for _, it := range inv.Contents[container] {
+		if it == item || isInside(inv, item, it) {
+			return true
+		}
+	}
+	return false
+}
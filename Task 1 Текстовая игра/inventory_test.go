@@ -0,0 +1,187 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const wardrobeWorld = `{
+	"start": "прихожая",
+	"items": [
+		{"name": "рюкзак", "capacity": 5, "wear": "спина"},
+		{"name": "сумка", "capacity": 3, "wear": "спина", "weight": 1},
+		{"name": "пенал", "capacity": 2, "weight": 1},
+		{"name": "шкаф", "capacity": 20, "fixed": true},
+		{"name": "ручка", "weight": 1},
+		{"name": "учебник", "weight": 3},
+		{"name": "гиря", "weight": 16}
+	],
+	"rooms": [
+		{"name": "прихожая", "items": ["шкаф", "рюкзак", "сумка", "пенал", "учебник", "гиря"],
+			"contents": {"шкаф": ["ручка"]}}
+	]
+}`
+
+func TestInventory_Containers(t *testing.T) {
+	w, err := ParseWorld([]byte(wardrobeWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+
+	playSteps(t, g, []gameCase{
+		{1, "взять учебник", "некуда класть"},
+		{2, "взять шкаф", "это не унести"},
+		{3, "надеть рюкзак", "вы надели: рюкзак"},
+		// слот занят - вторая сумка едет в рюкзаке
+		{4, "взять сумка", "предмет добавлен в инвентарь: сумка"},
+		{5, "взять пенал", "предмет добавлен в инвентарь: пенал"},
+		{6, "взять учебник", "предмет добавлен в инвентарь: учебник"},
+		{7, "взять гиря", "не хватает места"},
+		{8, "достать ручка из шкаф", "вы достали ручка (шкаф)"},
+		{9, "положить ручка в пенал", "вы положили ручка (пенал)"},
+		{10, "положить пенал в пенал", "нельзя положить предмет в самого себя"},
+		{11, "положить учебник в пенал", "не хватает места"},
+		{12, "положить учебник в ручка", "это не контейнер - ручка"},
+		{13, "положить учебник в шкаф", "вы положили учебник (шкаф)"},
+		{14, "положить пенал в сумка", "вы положили пенал (сумка)"},
+		{15, "положить рюкзак в сумка", "нельзя положить предмет в самого себя"},
+		{16, "достать учебник из рюкзак", "нет предмета - учебник (рюкзак)"},
+		{17, "положить гиря в шкаф", "нет предмета в инвентаре - гиря"},
+	})
+
+	want := []string{"рюкзак", "сумка", "пенал", "ручка"}
+	if got := g.player.allItems(); !reflect.DeepEqual(got, want) {
+		t.Errorf("inventory: expected %v got %v", want, got)
+	}
+	if got := g.weight("сумка", g.player.Contents); got != 3 {
+		t.Errorf("bag weight with contents: expected 3 got %d", got)
+	}
+	if !g.rooms["прихожая"].hasItem("учебник") {
+		t.Error("book is not in the wardrobe")
+	}
+}
+
+func TestInventory_TakeOutOfOwnContainer(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{2, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{3, "надеть рюкзак", "вы надели: рюкзак"},
+		{4, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		// другого контейнера нет - ключи оказываются в руках
+		{5, "достать ключи из рюкзака", "вы достали ключи (рюкзак)"},
+		{6, "инвентарь", "надето: рюкзак, в руках: ключи"},
+		{7, "положить ключи в рюкзак", "вы положили ключи (рюкзак)"},
+		{8, "взять ключи из рюкзака", "вы достали ключи (рюкзак)"},
+		{9, "инвентарь", "надето: рюкзак, в руках: ключи"},
+	})
+}
+
+func TestInventory_GiveMovesContents(t *testing.T) {
+	g := newSharedGame(t, "Kate", "Tristan")
+	kate, tristan := g.players["Kate"], g.players["Tristan"]
+	kate.room, tristan.room = g.rooms["комната"], g.rooms["комната"]
+
+	g.HandleCommand("Kate", "надеть рюкзак")
+	g.HandleCommand("Kate", "взять ключи")
+	g.HandleCommand("Kate", "отдать рюкзак Tristan")
+
+	if kate.hasItem("ключи") || !tristan.hasItem("ключи") {
+		t.Errorf("keys did not move with the backpack: kate=%v tristan=%v", kate.allItems(), tristan.allItems())
+	}
+	if container, _ := tristan.containerOf("ключи"); container != "рюкзак" {
+		t.Errorf("keys should stay in the backpack, got %q", container)
+	}
+}
+
+func TestInventory_SnapshotKeepsContents(t *testing.T) {
+	w, err := ParseWorld([]byte(wardrobeWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	playSteps(t, g, []gameCase{
+		{1, "надеть рюкзак", "вы надели: рюкзак"},
+		{2, "взять пенал", "предмет добавлен в инвентарь: пенал"},
+		{3, "достать ручка из шкаф", "вы достали ручка (шкаф)"},
+	})
+
+	restored := NewGame(w)
+	if err := restored.Restore(g.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g.Snapshot(), restored.Snapshot()) {
+		t.Errorf("snapshots differ:\n%+v\n%+v", g.Snapshot(), restored.Snapshot())
+	}
+}
+
+func TestInventory_ListAndDrop(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "инвентарь", "инвентарь пуст"},
+		{2, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{3, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{4, "надеть рюкзак", "вы надели: рюкзак"},
+		{5, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{6, "взять конспекты", "предмет добавлен в инвентарь: конспекты"},
+		{7, "инвентарь", "надето: рюкзак, рюкзак: ключи, конспекты"},
+		{8, "выбросить ключи", "предмет оставлен в комнате: ключи"},
+		{9, "осмотреться", "на полу: ключи. можно пройти - коридор"},
+		{10, "положить ключи", "нет предмета в инвентаре - ключи"},
+		{11, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{12, "идти кухня", "кухня, ничего интересного. можно пройти - коридор"},
+		// рюкзак выбрасывается вместе с конспектами
+		{13, "положить рюкзак", "предмет оставлен в комнате: рюкзак"},
+		{14, "инвентарь", "инвентарь пуст"},
+		{15, "осмотреться", "ты находишься на кухне, на столе: чай, на полу: рюкзак, надо собрать рюкзак и идти в универ. можно пройти - коридор"},
+		{16, "достать конспекты из рюкзак", "некуда класть"},
+		{17, "надеть рюкзак", "вы надели: рюкзак"},
+		{18, "инвентарь", "надето: рюкзак, рюкзак: конспекты"},
+	})
+}
+
+func TestInventory_SnapshotKeepsPlaces(t *testing.T) {
+	g := NewGame(testWorld(t))
+	g.player.room = g.rooms["комната"]
+	playSteps(t, g, []gameCase{
+		{1, "надеть рюкзак", "вы надели: рюкзак"},
+		{2, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{3, "выбросить ключи", "предмет оставлен в комнате: ключи"},
+	})
+
+	restored := NewGame(g.world)
+	if err := restored.Restore(g.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	want := "на столе: конспекты, на полу: ключи. можно пройти - коридор"
+	if got := restored.handleCommand("осмотреться"); got != want {
+		t.Errorf("expected %q\n\tgot %q", want, got)
+	}
+}
+
+func TestInventory_Validation(t *testing.T) {
+	data := `{
+		"start": "к",
+		"items": [{"name": "ключи", "capacity": 1}, {"name": "ключи"}, {"name": "призрак"}, {"name": "брелок", "weight": 1}, {"name": "кольцо", "weight": 1}, {"name": "сундук", "capacity": 5}],
+		"rooms": [{"name": "к", "items": ["ключи", "чай"], "contents": {"чай": ["сахар"], "ключи": ["брелок", "кольцо"], "шкаф": [], "сундук": ["сундук"]}}]
+	}`
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		`предмет "ключи" описан в каталоге несколько раз`,
+		`предмет "призрак" из каталога нигде не встречается`,
+		`"чай" не контейнер`,
+		`в "ключи" не помещается столько предметов`,
+		`"шкаф" не контейнер`,
+		// сундук лежит только в самом себе
+		`контейнера "сундук" нет в комнате`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q not reported:\n%v", want, err)
+		}
+	}
+}
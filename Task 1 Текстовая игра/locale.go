@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Locale - язык игры: глаголы и служебные слова для разбора команд
+// и перевод ответов. Имена комнат и предметов переводит сам мир
+type Locale struct {
+	Name  string
+	Title string
+	// Verbs - глаголы команд, как actionsAliases
+	Verbs map[string]string
+	// Fillers, Prepositions, Leading и Endings - как fillers, prepositions, leading и endings
+	Fillers      map[string]bool
+	Prepositions map[string][]string
+	Leading      map[string][]string
+	Endings      []string
+	// Messages - перевод ответов игры. Ключ - шаблон ответа, как он записан в коде,
+	// по-русски; для русского языка перевод не нужен
+	Messages map[string]string
+	// Commands - как записать команду, чтобы ее понял разбор: "go" - "идти %s".
+	// Нужны, когда команду предлагает сама игра, например кнопкой в чате
+	Commands map[string]string
+}
+
+// defaultLocale - язык, на котором написаны игра и встроенный мир
+const defaultLocale = "ru"
+
+var russian = &Locale{
+	Name:         "ru",
+	Title:        "русский",
+	Verbs:        actionsAliases,
+	Fillers:      fillers,
+	Prepositions: prepositions,
+	Leading:      leading,
+	Endings:      endings,
+	Commands: map[string]string{
+		"look":      "осмотреться",
+		"inventory": "инвентарь",
+		"take":      "взять %s",
+		"takeout":   "достать %s из %s",
+		"go":        "идти %s",
+		"use":       "применить %s %s",
+		"examine":   "осмотреть %s",
+		"talk":      "поговорить с %s",
+		"answer":    "ответить %s",
+		"open":      "открыть %s",
+		"close":     "закрыть %s",
+		"lock":      "запереть %s",
+		"break":     "сломать %s",
+		"combine":   "соединить %s и %s",
+	},
+}
+
+var english = &Locale{
+	Name:  "en",
+	Title: "English",
+	Verbs: map[string]string{
+		"take":        "take",
+		"get":         "take",
+		"grab":        "take",
+		"pick up":     "take",
+		"wear":        "take",
+		"put on":      "take",
+		"look":        "look",
+		"look around": "look",
+		"go":          "go",
+		"walk":        "go",
+		"use":         "use",
+		"apply":       "use",
+		"put":         "put",
+		"place":       "put",
+		"take out":    "takeout",
+		"drop":        "drop",
+		"inventory":   "inventory",
+		"inv":         "inventory",
+		"quit":        "exit",
+		"exit game":   "exit",
+		"save":        "save",
+		"load":        "load",
+		"say":         "say",
+		"whisper":     "whisper",
+		"tell":        "whisper",
+		"give":        "give",
+		"hand":        "give",
+		"undo":        "undo",
+		"redo":        "redo",
+		"history":     "history",
+		"goals":       "goals",
+		"talk":        "talk",
+		"speak":       "talk",
+		"answer":      "answer",
+		"reply":       "answer",
+		"examine":     "examine",
+		"inspect":     "examine",
+		"look at":     "examine",
+		"time":        "time",
+		"open":        "open",
+		"unlock":      "open",
+		"close":       "close",
+		"shut":        "close",
+		"lock":        "lock",
+		"break":       "break",
+		"smash":       "break",
+		"combine":     "combine",
+		"mix":         "combine",
+		"map":         "map",
+		"exits":       "exits",
+		"language":    "locale",
+		"язык":        "locale",
+	},
+	Fillers: map[string]bool{
+		"please": true,
+		"the":    true,
+		"a":      true,
+		"an":     true,
+		"this":   true,
+		"that":   true,
+		"these":  true,
+		"those":  true,
+	},
+	Prepositions: map[string][]string{
+		"take":    {"from", "off"},
+		"takeout": {"from", "of"},
+		"put":     {"in", "into", "on"},
+		"use":     {"on", "with", "to", "for"},
+		"give":    {"to"},
+		"combine": {"and", "with"},
+	},
+	Leading: map[string][]string{
+		"go":   {"to", "into", "through"},
+		"talk": {"to", "with"},
+	},
+	Endings: []string{"es", "s"},
+	Commands: map[string]string{
+		"look":      "look around",
+		"inventory": "inventory",
+		"take":      "take %s",
+		"takeout":   "take out %s from %s",
+		"go":        "go %s",
+		"use":       "use %s on %s",
+		"examine":   "examine %s",
+		"talk":      "talk to %s",
+		"answer":    "answer %s",
+		"open":      "open %s",
+		"close":     "close %s",
+		"lock":      "lock %s",
+		"break":     "break %s",
+		"combine":   "combine %s and %s",
+	},
+	Messages: map[string]string{
+		"Введите команду":                                "Enter a command",
+		"Спасибо за игру!":                               "Thanks for playing!",
+		"неизвестная команда":                            "unknown command",
+		"неизвестная команда, %s":                        "unknown command, %s",
+		"возможно, вы имели в виду: %s":                  "did you mean: %s",
+		"путь закрыт":                                    "the way is closed",
+		"ничего не произошло":                            "nothing happened",
+		"укажите предмет":                                "name an item",
+		"укажите направление":                            "name a direction",
+		"укажите предмет и объект":                       "name an item and an object",
+		"укажите, что и куда положить":                   "say what to put and where",
+		"укажите, что и откуда достать":                  "say what to take out and from where",
+		"укажите, что осмотреть":                         "say what to examine",
+		"укажите, с кем поговорить":                      "say who to talk to",
+		"укажите игрока":                                 "name a player",
+		"укажите предмет и игрока":                       "name an item and a player",
+		"нет пути в %s":                                  "no way to %s",
+		"нет предмета в инвентаре - %s":                  "no item in inventory - %s",
+		"не к чему применить":                            "nothing to use it on",
+		"на полу":                                        "on the floor",
+		"здесь: %s":                                      "here: %s",
+		"%s. можно пройти - %s":                          "%s. exits - %s",
+		"%s. Кроме вас тут ещё %s":                       "%s. Also here: %s",
+		"не хватает места":                               "not enough room",
+		"некуда класть":                                  "nowhere to put it",
+		"вы надели: %s":                                  "you put on: %s",
+		"предмет добавлен в инвентарь: %s":               "item added to inventory: %s",
+		"нет такого":                                     "no such thing",
+		"это не унести":                                  "you can't carry that",
+		"это не контейнер - %s":                          "not a container - %s",
+		"нельзя положить предмет в самого себя":          "an item can't be put into itself",
+		"%s уже внутри (%s)":                             "%s is already in %s",
+		"вы положили %s (%s)":                            "you put %s in %s",
+		"нет предмета - %s (%s)":                         "there is no %s in %s",
+		"вы достали %s (%s)":                             "you took %s out of %s",
+		"надето: %s":                                     "wearing: %s",
+		"в руках: %s":                                    "in hands: %s",
+		"инвентарь пуст":                                 "inventory is empty",
+		"предмет оставлен в комнате: %s":                 "item left in the room: %s",
+		"поражение: провалена цель - %s, очки: %d из %d": "defeat: goal failed - %s, score: %d of %d",
+		"победа! все цели выполнены, очки: %d из %d":     "victory! all goals completed, score: %d of %d",
+		"в этом мире нет целей":                          "this world has no goals",
+		"не выполнено":                                   "not done",
+		"выполнено":                                      "done",
+		"провалено":                                      "failed",
+		"%s, необязательно":                              "%s, optional",
+		"цели: %s. очки: %d из %d":                       "goals: %s. score: %d of %d",
+		"в общей игре отмена недоступна":                 "undo is not available in the shared game",
+		"в общей игре история недоступна":                "history is not available in the shared game",
+		"отменено: %s":                                   "undone: %s",
+		"нечего отменять":                                "nothing to undo",
+		"повторено: %s":                                  "redone: %s",
+		"нечего повторять":                               "nothing to redo",
+		"история пуста":                                  "history is empty",
+		"тут нет такого":                                 "there is no one like that here",
+		"ничего особенного":                              "nothing special",
+		"можно пройти - %s":                              "leads to: %s",
+		"внутри: %s":                                     "inside: %s",
+		"внутри пусто":                                   "empty inside",
+		"вы ни с кем не разговариваете":                  "you are not talking to anyone",
+		"нет такого варианта":                            "no such option",
+		"разговор окончен":                               "the conversation is over",
+		"ход %d":                                         "turn %d",
+		"что сказать?":                                   "say what?",
+		"%s говорит: %s":                                 "%s says: %s",
+		"тут нет такого игрока":                          "there is no such player here",
+		"%s выразительно молчит, смотря на вас":          "%s looks at you in meaningful silence",
+		"%s говорит вам: %s":                             "%s tells you: %s",
+		"вы выразительно молчите, смотря на %s":          "you look at %s in meaningful silence",
+		"вы шепнули %s: %s":                              "you whispered to %s: %s",
+		"%s некуда класть":                               "%s has nowhere to put it",
+		"%s передаёт вам: %s":                            "%s gives you: %s",
+		"вы передали %s: %s":                             "you gave %s: %s",
+		"сохранение недоступно":                          "saving is not available",
+		"в общей игре сохранение недоступно":             "saving is not available in the shared game",
+		"некорректное имя сохранения":                    "invalid save name",
+		"не удалось сохранить игру":                      "could not save the game",
+		"игра сохранена: %s":                             "game saved: %s",
+		"нет такого сохранения":                          "no such save",
+		"не удалось загрузить игру":                      "could not load the game",
+		"игра загружена: %s":                             "game loaded: %s",
+		"язык: %s":                                       "language: %s",
+		"язык: %s, доступны: %s":                         "language: %s, available: %s",
+		"нет такого языка, доступны: %s":                 "no such language, available: %s",
+		"выходов нет":                                    "no exits",
+		"выходы: %s":                                     "exits: %s",
+		"вы здесь":                                       "you are here",
+		"укажите объект":                                 "name an object",
+		"нужен предмет - %s":                             "you need: %s",
+		"так нельзя":                                     "you can't do that",
+		"заперто":                                        "locked",
+		"закрыто":                                        "closed",
+		"открыто":                                        "open",
+		"сломано":                                        "broken",
+		"укажите, что с чем соединить":                   "say what to combine with what",
+		"нельзя соединить предмет с самим собой":         "an item can't be combined with itself",
+		"из этого ничего не выйдет":                      "nothing comes of it",
+		"получено: %s":                                   "you made: %s",
+		"такой предмет уже есть - %s":                    "that item already exists: %s",
+		"Добро пожаловать в квест!":                      "Welcome to the quest!",
+		"слишком много команд, подождите немного":        "too many commands, please wait a little",
+		"Как вас зовут?":                                 "What is your name?",
+		"сервер переполнен, попробуйте позже":            "the server is full, try again later",
+		"время ожидания истекло":                         "timed out",
+		"нет такого игрока":                              "no such player",
+		"некорректное имя игрока %q":                     "invalid player name %q",
+		"игрок %s уже в игре":                            "player %s is already in the game",
+
+		// подсказка консольной игры
+		"Доступные команды: взять, осмотреться, идти, применить, выход":                   "Available commands: take, look around, go, use, quit",
+		"Например: 'осмотреться', 'взять ключи', 'идти коридор', 'применить ключи дверь'": "For example: 'look around', 'take keys', 'go hallway', 'use keys on door'",
+		"Введите 'выйти из игры' для выхода":                                              "Type 'quit' to leave the game",
+	},
+}
+
+// locales - языки, на которых можно играть
+var locales = map[string]*Locale{
+	russian.Name: russian,
+	english.Name: english,
+}
+
+// localeNames - коды языков по алфавиту
+func localeNames() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// localeOf - язык игрока. Язык хранится по имени игрока, а не в нем самом,
+// чтобы пережить отмену и загрузку сохранения. Пока игрок язык не выбрал,
+// ему отвечают на языке игры - языке игрока одиночной игры
+func (g *Game) localeOf(player *Player) *Locale {
+	if loc, ok := g.locales[player.Name]; ok {
+		return loc
+	}
+	if loc, ok := g.locales[""]; ok {
+		return loc
+	}
+	return russian
+}
+
+// tr переводит ответ игры на язык игрока и подставляет в него аргументы
+func (g *Game) tr(player *Player, format string, args ...interface{}) string {
+	if translated, ok := g.localeOf(player).Messages[format]; ok {
+		format = translated
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// translate переводит ответ на язык name, когда игры еще нет, например
+// приветствие сервера. Неизвестный язык - без перевода
+func translate(name, text string) string {
+	if loc, ok := locales[name]; ok {
+		if translated, ok := loc.Messages[text]; ok {
+			return translated
+		}
+	}
+	return text
+}
+
+// local переводит текст из описания мира: имя, описание, реплику
+func (g *Game) local(player *Player, text string) string {
+	if translated, ok := g.world.Translations[g.localeOf(player).Name][text]; ok {
+		return translated
+	}
+	return text
+}
+
+func (g *Game) localList(player *Player, texts []string) []string {
+	result := make([]string, 0, len(texts))
+	for _, text := range texts {
+		result = append(result, g.local(player, text))
+	}
+	return result
+}
+
+// format записывает команду словами языка, в шаблон подставляются Object и Target
+func (loc *Locale) format(cmd Command) string {
+	template := loc.Commands[cmd.Verb]
+	args := []interface{}{cmd.Object, cmd.Target}[:strings.Count(template, "%s")]
+	return fmt.Sprintf(template, args...)
+}
+
+// original возвращает имя из описания мира по имени, которое назвал игрок
+func original(name string, shown, known []string) string {
+	for i := range shown {
+		if shown[i] == name {
+			return known[i]
+		}
+	}
+	return name
+}
+
+// setLocale - команда "язык": без аргумента называет текущий язык и доступные
+func (g *Game) setLocale(player *Player, name string) string {
+	available := strings.Join(localeNames(), ", ")
+	if name == "" {
+		return g.tr(player, "язык: %s, доступны: %s", g.localeOf(player).Title, available)
+	}
+	loc, ok := locales[strings.ToLower(name)]
+	if !ok {
+		return g.tr(player, "нет такого языка, доступны: %s", available)
+	}
+	g.locales[player.Name] = loc
+	return g.tr(player, "язык: %s", loc.Title)
+}
+
+// SetLocale выбирает язык одиночной игры
+func (g *Game) SetLocale(name string) error {
+	return g.SetPlayerLocale("", name)
+}
+
+// SetPlayerLocale выбирает язык игрока общего мира, пустое имя - игрок одиночной игры
+func (g *Game) SetPlayerLocale(player, name string) error {
+	loc, ok := locales[name]
+	if !ok {
+		return fmt.Errorf("неизвестный язык %q, доступны: %s", name, strings.Join(localeNames(), ", "))
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.locales[player] = loc
+	return nil
+}
+
+// Translate переводит ответ игры на язык игрока, пустое имя - игрок одиночной игры.
+// Нужен фронтендам, чтобы узнавать ответы вроде прощания
+func (g *Game) Translate(player, text string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tr(&Player{Name: player}, text)
+}
+
+// validateTranslations проверяет переводы мира: язык должен быть известен,
+// а разные имена комнат, предметов, объектов и персонажей не должны совпасть
+// в переводе, иначе игрок не сможет их различить
+func (w *World) validateTranslations(addErr func(format string, args ...interface{})) {
+	names := make(map[string]bool)
+	for _, r := range w.Rooms {
+		names[r.Name] = true
+		for _, item := range r.Items {
+			names[item] = true
+		}
+		for _, contents := range r.Contents {
+			for _, item := range contents {
+				names[item] = true
+			}
+		}
+		for _, obj := range r.Objects {
+			names[obj.Name] = true
+			if obj.Exit != "" {
+				names[obj.Exit] = true
+			}
+		}
+	}
+	for _, npc := range w.NPCs {
+		names[npc.Name] = true
+	}
+	langs := make([]string, 0, len(w.Translations))
+	for lang := range w.Translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		if _, ok := locales[lang]; !ok {
+			addErr("переводы: неизвестный язык %q", lang)
+			continue
+		}
+		sources := make([]string, 0, len(w.Translations[lang]))
+		for text := range w.Translations[lang] {
+			sources = append(sources, text)
+		}
+		sort.Strings(sources)
+		seen := make(map[string]string)
+		for _, text := range sources {
+			translated := w.Translations[lang][text]
+			if translated == "" {
+				addErr("переводы %s: пустой перевод для %q", lang, text)
+				continue
+			}
+			if !names[text] {
+				continue
+			}
+			if other, ok := seen[translated]; ok {
+				addErr("переводы %s: %q и %q переводятся одинаково - %q", lang, other, text, translated)
+			}
+			seen[translated] = text
+		}
+	}
+}
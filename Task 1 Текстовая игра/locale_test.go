@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocale_EnglishCourse(t *testing.T) {
+	g := NewGame(testWorld(t))
+	if err := g.SetLocale("en"); err != nil {
+		t.Fatal(err)
+	}
+	playSteps(t, g, []gameCase{
+		{1, "look around", "you are in the kitchen, on the table: tea, pack the backpack and go to uni. exits - hallway"},
+		{2, "go to the hallway", "nothing interesting. exits - kitchen, room, street"},
+		{3, "go room", "you are in your room. exits - hallway"},
+		{4, "put on backpack", "you put on: backpack"},
+		{5, "take keys", "item added to inventory: keys"},
+		{6, "take notes", "item added to inventory: notes"},
+		// другого контейнера нет - ключи достаются в руки
+		{7, "take out the keys from backpack", "you took keys out of backpack"},
+		{8, "put keys in backpack", "you put keys in backpack"},
+		{9, "take tea", "no such thing"},
+		{10, "inventory", "wearing: backpack, backpack: notes, keys"},
+		{11, "go hallway", "nothing interesting. exits - kitchen, room, street"},
+		{12, "go street", "the door is closed"},
+		{13, "use keys on door", "the door is open"},
+		{14, "eat breakfast", "unknown command"},
+		{15, "go to the street", "it is spring outside. exits - home"},
+	})
+	summary, over := g.Result()
+	if !over || summary != "victory! all goals completed, score: 30 of 35" {
+		t.Errorf("unexpected result %q %v", summary, over)
+	}
+	want := State{Room: "street", Inventory: []string{"backpack", "notes", "keys"}, Exits: []string{"home"}, Score: 30, Finished: true, Summary: summary}
+	if state := g.State(); !reflect.DeepEqual(state, want) {
+		t.Errorf("expected state %+v\n\tgot %+v", want, state)
+	}
+}
+
+func TestLocale_SwitchCommand(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "язык", "язык: русский, доступны: en, ru"},
+		{2, "язык fr", "нет такого языка, доступны: en, ru"},
+		{3, "язык en", "language: English"},
+		{4, "go hallway", "nothing interesting. exits - kitchen, room, street"},
+		{5, "идти кухня", "unknown command"},
+		{6, "undo", "undone: go hallway"},
+		{7, "language ru", "язык: русский"},
+		{8, "осмотреться", "ты находишься на кухне, на столе: чай, надо собрать рюкзак и идти в универ. можно пройти - коридор"},
+	})
+}
+
+func TestLocale_SharedWorld(t *testing.T) {
+	g := newSharedGame(t, "Kate", "Tristan")
+	if err := g.SetPlayerLocale("Tristan", "en"); err != nil {
+		t.Fatal(err)
+	}
+	want := []Message{{"Kate", "Kate говорит: привет"}, {"Tristan", "Kate says: привет"}}
+	if got := g.HandleCommand("Kate", "сказать привет"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+	want = []Message{{"Tristan", "you are in the kitchen, on the table: tea, pack the backpack and go to uni. exits - hallway. Also here: Kate"}}
+	if got := g.HandleCommand("Tristan", "look"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+	if err := g.SetPlayerLocale("Kate", "fr"); err == nil {
+		t.Error("unknown locale must be rejected")
+	}
+}
+
+func TestLocale_WorldTranslations(t *testing.T) {
+	data := strings.Replace(string(defaultWorld), `"чай": "tea"`, `"чай": "keys", "x": ""`, 1)
+	data = strings.Replace(data, `"en": {`, `"fr": {}, "en": {`, 1)
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected translation errors")
+	}
+	for _, want := range []string{
+		`переводы: неизвестный язык "fr"`,
+		`переводы en: пустой перевод для "x"`,
+		`переводы en: "ключи" и "чай" переводятся одинаково - "keys"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q in\n%v", want, err)
+		}
+	}
+}
+
+func TestLocale_HTTPSession(t *testing.T) {
+	api := NewAPI(testWorld(t))
+	code, created := doJSON(t, api, "POST", "/sessions", `{"locale": "en"}`)
+	if code != http.StatusCreated || created.State.Room != "kitchen" {
+		t.Fatalf("unexpected response %d %+v", code, created)
+	}
+	_, resp := doJSON(t, api, "POST", "/sessions/"+created.ID+"/commands", `{"command": "go hallway"}`)
+	if resp.Reply != "nothing interesting. exits - kitchen, room, street" {
+		t.Errorf("unexpected reply %q", resp.Reply)
+	}
+	if code, _ := doJSON(t, api, "POST", "/sessions", `{"locale": "fr"}`); code != http.StatusBadRequest {
+		t.Errorf("expected %d for unknown locale, got %d", http.StatusBadRequest, code)
+	}
+}
+
+func TestLocale_ServerAndReplay(t *testing.T) {
+	srv := &Server{World: testWorld(t), Shared: true, MaxConns: 2, Locale: "en"}
+	addr := startServer(t, srv)
+	kate := dial(t, addr)
+	kate.expect("Welcome to the quest!")
+	kate.expect("What is your name?")
+	kate.send("Kate Bell")
+	kate.expect(`invalid player name "Kate Bell"`)
+	kate.expect("What is your name?")
+	kate.send("Kate")
+
+	tristan := dial(t, addr)
+	tristan.expect("Welcome to the quest!")
+	tristan.expect("What is your name?")
+	tristan.send("Kate")
+	tristan.expect("player Kate is already in the game")
+	tristan.expect("What is your name?")
+	tristan.send("Tristan")
+	tristan.send("whisper Kate hi")
+	tristan.expect("you whispered to Kate: hi")
+	kate.expect("Tristan tells you: hi")
+
+	dial(t, addr).expect("the server is full, try again later")
+	want := []Message{{"Bob", "no such player"}}
+	if got := srv.game.HandleCommand("Bob", "look"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v got %v", want, got)
+	}
+
+	idle := dial(t, startServer(t, &Server{World: testWorld(t), IdleTimeout: 50 * time.Millisecond, Locale: "en"}))
+	idle.expect("Welcome to the quest!")
+	idle.expect("timed out")
+
+	// сессия, записанная на английском, проигрывается на нем же
+	var buf bytes.Buffer
+	WriteStep(&buf, "go hallway", "nothing interesting. exits - kitchen, room, street")
+	transcript, err := ParseTranscript(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := Replay(testWorld(t), transcript, "en"); d != nil {
+		t.Errorf("unexpected divergence:\n%v", d)
+	}
+	if d := Replay(testWorld(t), transcript, ""); d == nil || d.Got != "неизвестная команда" {
+		t.Errorf("russian replay must diverge, got %v", d)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+)
+
+type gameCase struct {
+	step    int
+	command string
+	answer  string
+}
+
+var gameCases = [][]gameCase{
+	{
+		{1, "осмотреться", "ты находишься на кухне, на столе: чай, надо собрать рюкзак и идти в универ. можно пройти - коридор"},
+		{2, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{3, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{4, "осмотреться", "на столе: ключи, конспекты, на стуле: рюкзак. можно пройти - коридор"},
+		{5, "надеть рюкзак", "вы надели: рюкзак"},
+		{6, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{7, "взять конспекты", "предмет добавлен в инвентарь: конспекты"},
+		{8, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{9, "применить ключи дверь", "дверь открыта"},
+		{10, "идти улица", "на улице весна. можно пройти - домой"},
+	},
+	{
+		{1, "осмотреться", "ты находишься на кухне, на столе: чай, надо собрать рюкзак и идти в универ. можно пройти - коридор"},
+		{2, "завтракать", "неизвестная команда"},
+		{3, "идти комната", "нет пути в комната"},
+		{4, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{5, "применить ключи дверь", "нет предмета в инвентаре - ключи"},
+		{6, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{7, "осмотреться", "на столе: ключи, конспекты, на стуле: рюкзак. можно пройти - коридор"},
+		{8, "взять ключи", "некуда класть"},
+		{9, "надеть рюкзак", "вы надели: рюкзак"},
+		{10, "осмотреться", "на столе: ключи, конспекты. можно пройти - коридор"},
+		{11, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{12, "взять телефон", "нет такого"},
+		{13, "взять ключи", "нет такого"},
+		{14, "осмотреться", "на столе: конспекты. можно пройти - коридор"},
+		{15, "взять конспекты", "предмет добавлен в инвентарь: конспекты"},
+		{16, "осмотреться", "пустая комната. можно пройти - коридор"},
+		{17, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{18, "идти кухня", "кухня, ничего интересного. можно пройти - коридор"},
+		{19, "осмотреться", "ты находишься на кухне, на столе: чай, надо идти в универ. можно пройти - коридор"},
+		{20, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{21, "идти улица", "дверь закрыта"},
+		{22, "применить ключи дверь", "дверь открыта"},
+		{23, "идти улица", "на улице весна. можно пройти - домой"},
+		{24, "применить телефон шкаф", "нет предмета в инвентаре - телефон"},
+		{25, "применить ключи шкаф", "не к чему применить"},
+	},
+}
+
+func TestGame(t *testing.T) {
+	for caseNum, commands := range gameCases {
+		initGame()
+		for _, item := range commands {
+			answer := handleCommand(item.command)
+			if answer != item.answer {
+				t.Errorf("case %d, step %d, %q:\n\texpected %q\n\tgot      %q",
+					caseNum, item.step, item.command, item.answer, answer)
+			}
+		}
+	}
+}
+
+func TestGame_IndependentSessions(t *testing.T) {
+	w, err := ParseWorld(defaultWorld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for caseNum, commands := range gameCases {
+		for session := 0; session < 5; session++ {
+			caseNum, commands := caseNum, commands
+			t.Run("", func(t *testing.T) {
+				t.Parallel()
+				g := NewGame(w)
+				for _, item := range commands {
+					answer := g.handleCommand(item.command)
+					if answer != item.answer {
+						t.Errorf("case %d, step %d, %q:\n\texpected %q\n\tgot      %q",
+							caseNum, item.step, item.command, item.answer, answer)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGame_SessionsDoNotShareState(t *testing.T) {
+	w, err := ParseWorld(defaultWorld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := NewGame(w), NewGame(w)
+	for _, command := range []string{"идти коридор", "идти комната", "надеть рюкзак", "взять ключи"} {
+		first.handleCommand(command)
+	}
+	if got := second.handleCommand("осмотреться"); got != gameCases[0][0].answer {
+		t.Errorf("second game moved together with the first one: %q", got)
+	}
+	second.handleCommand("идти коридор")
+	if got := second.handleCommand("идти комната"); got != "ты в своей комнате. можно пройти - коридор" {
+		t.Fatalf("unexpected answer: %q", got)
+	}
+	want := "на столе: ключи, конспекты, на стуле: рюкзак. можно пройти - коридор"
+	if got := second.handleCommand("осмотреться"); got != want {
+		t.Errorf("second game sees items taken in the first one:\n\texpected %q\n\tgot      %q", want, got)
+	}
+}
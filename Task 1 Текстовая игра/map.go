@@ -0,0 +1,158 @@
+package main
+
+import (
+	"strings"
+)
+
+// route - выход из комнаты: объект с действием go
+type route struct {
+	// name - как выход называет игрок, to - куда он ведет
+	name string
+	to   string
+	// open - через выход сейчас можно пройти, state - состояние его объекта
+	open  bool
+	state string
+}
+
+// routes собирает выходы комнаты из действий go ее объектов, как exits.
+// Переход выполняет первое действие go объекта, по нему и его состоянию
+// судим, открыт ли выход
+func (g *Game) routes(player *Player, room *Room) []route {
+	var routes []route
+	for _, name := range room.objectOrder {
+		obj, ok := room.Objects[name]
+		if !ok {
+			continue
+		}
+		for _, action := range obj.Actions {
+			if action.action != ActionGo {
+				continue
+			}
+			if obj.Exit != "" {
+				name = obj.Exit
+			}
+			open := action.allows(obj.State) && g.check(action.when, player)
+			routes = append(routes, route{name: name, to: destination(action), open: open, state: obj.State})
+			break
+		}
+	}
+	return routes
+}
+
+// exitsText - команда "выходы": выходы из комнаты и открыты ли они. Выход,
+// закрытый условием, а не состоянием объекта, считается просто закрытым
+func (g *Game) exitsText(player *Player) string {
+	routes := g.routes(player, player.room)
+	if len(routes) == 0 {
+		return g.tr(player, "выходов нет")
+	}
+	parts := make([]string, 0, len(routes))
+	for _, r := range routes {
+		state := StateClosed
+		switch {
+		case r.open:
+			state = StateOpen
+		case r.state == StateLocked || r.state == StateBroken:
+			state = r.state
+		}
+		parts = append(parts, g.local(player, r.name)+" - "+g.tr(player, stateNames[state]))
+	}
+	return g.tr(player, "выходы: %s", strings.Join(parts, ", "))
+}
+
+// visit отмечает комнату на карте игрока
+func (p *Player) visit(room *Room) {
+	if p.visited == nil {
+		p.visited = make(map[string]bool)
+	}
+	p.visited[room.Name] = true
+}
+
+// mapText - команда "карта": дерево комнат, в которых игрок побывал, от стартовой.
+// Каждая комната рисуется один раз, в квадратных скобках, под той, откуда до нее
+// ближе всего; другие переходы в нее показаны ссылкой без скобок, а выходы
+// в неизвестные комнаты - знаком "?"
+//
+//	[кухня]
+//	`-- [коридор]
+//	    |-- [комната] <- вы здесь
+//	    `-- улица -> ?
+func (g *Game) mapText(player *Player) string {
+	parent := make(map[string]string)
+	placed := make(map[string]bool)
+	var roots []string
+	place := func(root string) {
+		roots = append(roots, root)
+		placed[root] = true
+		queue := []string{root}
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			for _, r := range g.routes(player, g.rooms[name]) {
+				if player.visited[r.to] && !placed[r.to] {
+					placed[r.to] = true
+					parent[r.to] = name
+					queue = append(queue, r.to)
+				}
+			}
+		}
+	}
+	if player.visited[g.world.Start] {
+		place(g.world.Start)
+	}
+	// в комнату можно попасть и не по выходам, например эффектом move
+	for _, room := range g.world.Rooms {
+		if player.visited[room.Name] && !placed[room.Name] {
+			place(room.Name)
+		}
+	}
+
+	var lines []string
+	var draw func(name, label, prefix, indent string)
+	draw = func(name, label, prefix, indent string) {
+		label += "[" + g.local(player, name) + "]"
+		if name == player.room.Name {
+			label += " <- " + g.tr(player, "вы здесь")
+		}
+		lines = append(lines, prefix+label)
+		type entry struct {
+			r     route
+			child bool
+		}
+		var entries []entry
+		drawn := make(map[string]bool)
+		for _, r := range g.routes(player, g.rooms[name]) {
+			if r.to != "" && r.to == parent[name] {
+				continue
+			}
+			child := r.to != "" && parent[r.to] == name && !drawn[r.to]
+			if child {
+				drawn[r.to] = true
+			}
+			entries = append(entries, entry{r, child})
+		}
+		for i, e := range entries {
+			branch, next := "|-- ", "|   "
+			if i == len(entries)-1 {
+				branch, next = "`-- ", "    "
+			}
+			exit := g.local(player, e.r.name)
+			switch {
+			case e.child:
+				label := ""
+				if e.r.name != e.r.to {
+					label = exit + " -> "
+				}
+				draw(e.r.to, label, indent+branch, indent+next)
+			case player.visited[e.r.to]:
+				lines = append(lines, indent+branch+exit+" -> "+g.local(player, e.r.to))
+			default:
+				lines = append(lines, indent+branch+exit+" -> ?")
+			}
+		}
+	}
+	for _, root := range roots {
+		draw(root, "", "", "")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMap_DefaultWorld(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "карта", "[кухня] <- вы здесь\n`-- коридор -> ?"},
+		{2, "выходы", "выходы: коридор - открыто"},
+		{3, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{4, "выходы", "выходы: кухня - открыто, комната - открыто, улица - заперто"},
+		{5, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{6, "карта", "[кухня]\n`-- [коридор]\n    |-- [комната] <- вы здесь\n    `-- улица -> ?"},
+		{7, "надеть рюкзак", "вы надели: рюкзак"},
+		{8, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{9, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{10, "применить ключи дверь", "дверь открыта"},
+		{11, "выходы", "выходы: кухня - открыто, комната - открыто, улица - открыто"},
+		{12, "отменить", "отменено: применить ключи дверь"},
+		{13, "выходы", "выходы: кухня - открыто, комната - открыто, улица - заперто"},
+		{14, "language en", "language: English"},
+		{15, "map", "[kitchen]\n`-- [hallway] <- you are here\n    |-- [room]\n    `-- street -> ?"},
+		{16, "exits", "exits: kitchen - open, room - open, street - locked"},
+	})
+}
+
+// в карте переход по выходу с другим именем подписан, а уже нарисованная
+// комната показана ссылкой
+func TestMap_NamedExitsAndLoops(t *testing.T) {
+	w, err := ParseWorld([]byte(`{
+		"start": "двор",
+		"rooms": [
+			{"name": "двор", "description": "двор", "items": [], "objects": [
+				{"name": "калитка", "actions": [{"type": "go", "to": "сад"}]},
+				{"name": "дом", "actions": [{"type": "go", "to": "дом"}]}
+			]},
+			{"name": "сад", "description": "сад", "items": [], "objects": [
+				{"name": "тропинка", "actions": [{"type": "go", "to": "дом"}]}
+			]},
+			{"name": "дом", "description": "дом", "items": [], "objects": [
+				{"name": "двор", "actions": [{"type": "go", "to": "двор"}]},
+				{"name": "погреб", "actions": [{"type": "go", "to": "погреб"}]}
+			]},
+			{"name": "погреб", "description": "погреб", "items": [], "objects": [
+				{"name": "дом", "actions": [{"type": "go", "to": "дом"}]}
+			]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	g.handleCommand("идти калитка")
+	g.handleCommand("идти тропинка")
+	want := "[двор]\n|-- калитка -> [сад]\n|   `-- тропинка -> дом\n`-- [дом] <- вы здесь\n    `-- погреб -> ?"
+	if got := g.handleCommand("карта"); got != want {
+		t.Errorf("expected map\n%s\ngot\n%s", want, got)
+	}
+
+	restored := NewGame(w)
+	if err := restored.Restore(g.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.handleCommand("карта"); got != want {
+		t.Errorf("restored game lost the map:\n%s", got)
+	}
+}
+
+func TestMap_Transcript(t *testing.T) {
+	g := NewGame(testWorld(t))
+	var buf bytes.Buffer
+	for _, command := range []string{"идти коридор", "карта", "выходы"} {
+		if err := WriteStep(&buf, command, g.handleCommand(command)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transcript, err := ParseTranscript(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transcript) != 3 || transcript[1].Expect != "[кухня]\n`-- [коридор] <- вы здесь\n    |-- комната -> ?\n    `-- улица -> ?" {
+		t.Fatalf("unexpected transcript %+v", transcript)
+	}
+	if d := Replay(testWorld(t), transcript, ""); d != nil {
+		t.Error(d)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Message - ответ игры, адресованный конкретному игроку
+type Message struct {
+	To   string
+	Text string
+}
+
+// AddPlayer добавляет в общий мир нового игрока. Он появляется в стартовой комнате
+func (g *Game) AddPlayer(name string) (*Player, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// игрока еще нет, отказ - на языке игры
+	stranger := &Player{}
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return nil, errors.New(g.tr(stranger, "некорректное имя игрока %q", name))
+	}
+	if _, ok := g.players[name]; ok {
+		return nil, errors.New(g.tr(stranger, "игрок %s уже в игре", name))
+	}
+	p := &Player{
+		Name:      name,
+		Inventory: Inventory{Items: []string{}},
+		room:      g.rooms[g.world.Start],
+	}
+	p.visit(p.room)
+	g.players[name] = p
+	g.order = append(g.order, name)
+	return p, nil
+}
+
+// RemovePlayer убирает игрока из мира. Предметы в мире уникальны, поэтому
+// его вещи вместе с содержимым остаются в комнате, где он стоял
+func (g *Game) RemovePlayer(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.players[name]
+	if !ok {
+		return
+	}
+	for _, item := range append([]string{}, p.Items...) {
+		g.dropItem(p, item)
+	}
+	delete(g.players, name)
+	delete(g.locales, name)
+	for i, n := range g.order {
+		if n == name {
+			g.order = append(g.order[:i], g.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// HandleCommand выполняет команду от имени игрока name и возвращает
+// все сообщения, которые она породила: ответ самому игроку и реплики другим
+func (g *Game) HandleCommand(name, command string) []Message {
+	var reply string
+	var messages []Message
+	known := true
+	g.turnLocked(func() {
+		p, ok := g.players[name]
+		if !ok {
+			known = false
+			return
+		}
+		g.outbox = nil
+		reply = g.resolveReaction(command, p)
+		messages = g.outbox
+		g.outbox = nil
+	})
+	if !known {
+		return []Message{{To: name, Text: g.Translate(name, "нет такого игрока")}}
+	}
+	if reply != "" {
+		messages = append([]Message{{To: name, Text: reply}}, messages...)
+	}
+	return messages
+}
+
+// notify ставит сообщение для игрока в очередь текущей команды
+func (g *Game) notify(to *Player, text string) {
+	g.outbox = append(g.outbox, Message{To: to.Name, Text: text})
+}
+
+// playersIn возвращает остальных игроков в той же комнате в порядке входа в игру
+func (g *Game) playersIn(room *Room, except *Player) []*Player {
+	var result []*Player
+	for _, name := range g.order {
+		p := g.players[name]
+		if p != except && p.room == room {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// lookAround - описание комнаты с перечислением других игроков в ней
+func (g *Game) lookAround(player *Player) string {
+	description := g.describe(player.room, player)
+	others := g.playersIn(player.room, player)
+	if len(others) == 0 {
+		return description
+	}
+	names := make([]string, 0, len(others))
+	for _, p := range others {
+		names = append(names, p.Name)
+	}
+	return g.tr(player, "%s. Кроме вас тут ещё %s", description, strings.Join(names, ", "))
+}
+
+// say - реплика для всех игроков в комнате, включая самого говорящего
+func (g *Game) say(player *Player, text string) (bool, string) {
+	if text == "" {
+		return false, g.tr(player, "что сказать?")
+	}
+	for _, p := range g.playersIn(player.room, player) {
+		g.notify(p, g.tr(p, "%s говорит: %s", player.Name, text))
+	}
+	return true, g.tr(player, "%s говорит: %s", player.Name, text)
+}
+
+// whisper - реплика, которую слышит только один игрок в той же комнате
+func (g *Game) whisper(player *Player, to, text string) (bool, string) {
+	target := g.playerInRoom(player, to)
+	if target == nil {
+		return false, g.tr(player, "тут нет такого игрока")
+	}
+	if text == "" {
+		g.notify(target, g.tr(target, "%s выразительно молчит, смотря на вас", player.Name))
+		return true, g.tr(player, "вы выразительно молчите, смотря на %s", target.Name)
+	}
+	g.notify(target, g.tr(target, "%s говорит вам: %s", player.Name, text))
+	return true, g.tr(player, "вы шепнули %s: %s", target.Name, text)
+}
+
+// give передает предмет из инвентаря другому игроку в той же комнате
+func (g *Game) give(player *Player, item, to string) (bool, string) {
+	if !player.hasItem(item) {
+		return false, g.tr(player, "нет предмета в инвентаре - %s", g.local(player, item))
+	}
+	target := g.playerInRoom(player, to)
+	if target == nil {
+		return false, g.tr(player, "тут нет такого игрока")
+	}
+	if _, ok := g.stow(target, &player.Inventory, item, ""); !ok {
+		return false, g.tr(player, "%s некуда класть", target.Name)
+	}
+	g.notify(target, g.tr(target, "%s передаёт вам: %s", player.Name, g.local(target, item)))
+	return true, g.tr(player, "вы передали %s: %s", target.Name, g.local(player, item))
+}
+
+func (g *Game) playerInRoom(player *Player, name string) *Player {
+	target, ok := g.players[name]
+	if !ok || target == player || target.room != player.room {
+		return nil
+	}
+	return target
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newSharedGame(t *testing.T, names ...string) *Game {
+	t.Helper()
+	w, err := ParseWorld(defaultWorld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	for _, name := range names {
+		if _, err := g.AddPlayer(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestMultiplayer_Commands(t *testing.T) {
+	g := newSharedGame(t, "Kate", "Tristan")
+
+	cases := []struct {
+		player  string
+		command string
+		want    []Message
+	}{
+		{"Kate", "осмотреться", []Message{{"Kate", "ты находишься на кухне, на столе: чай, надо собрать рюкзак и идти в универ. можно пройти - коридор. Кроме вас тут ещё Tristan"}}},
+		{"Kate", "сказать привет всем", []Message{{"Kate", "Kate говорит: привет всем"}, {"Tristan", "Kate говорит: привет всем"}}},
+		{"Tristan", "сказать_игроку Kate как дела", []Message{{"Tristan", "вы шепнули Kate: как дела"}, {"Kate", "Tristan говорит вам: как дела"}}},
+		{"Tristan", "сказать_игроку Kate", []Message{{"Tristan", "вы выразительно молчите, смотря на Kate"}, {"Kate", "Tristan выразительно молчит, смотря на вас"}}},
+		{"Tristan", "сказать_игроку Bob привет", []Message{{"Tristan", "тут нет такого игрока"}}},
+		{"Kate", "идти коридор", []Message{{"Kate", "ничего интересного. можно пройти - кухня, комната, улица"}}},
+		{"Kate", "осмотреться", []Message{{"Kate", "ничего интересного. можно пройти - кухня, комната, улица"}}},
+		{"Tristan", "сказать_игроку Kate ты где", []Message{{"Tristan", "тут нет такого игрока"}}},
+		{"Kate", "идти комната", []Message{{"Kate", "ты в своей комнате. можно пройти - коридор"}}},
+		{"Kate", "надеть рюкзак", []Message{{"Kate", "вы надели: рюкзак"}}},
+		{"Kate", "взять ключи", []Message{{"Kate", "предмет добавлен в инвентарь: ключи"}}},
+		{"Tristan", "идти коридор", []Message{{"Tristan", "ничего интересного. можно пройти - кухня, комната, улица"}}},
+		{"Tristan", "идти комната", []Message{{"Tristan", "ты в своей комнате. можно пройти - коридор"}}},
+		{"Kate", "отдать ключи Tristan", []Message{{"Kate", "Tristan некуда класть"}}},
+		{"Tristan", "взять конспекты", []Message{{"Tristan", "некуда класть"}}},
+		{"Kate", "отдать рюкзак Tristan", []Message{{"Kate", "вы передали Tristan: рюкзак"}, {"Tristan", "Kate передаёт вам: рюкзак"}}},
+		// ключи лежали в рюкзаке и ушли вместе с ним
+		{"Kate", "отдать ключи Tristan", []Message{{"Kate", "нет предмета в инвентаре - ключи"}}},
+		{"Tristan", "отдать ключи Kate", []Message{{"Tristan", "Kate некуда класть"}}},
+		{"Tristan", "взять конспекты", []Message{{"Tristan", "предмет добавлен в инвентарь: конспекты"}}},
+	}
+	for i, c := range cases {
+		got := g.HandleCommand(c.player, c.command)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("step %d, %s: %q:\n\texpected %v\n\tgot      %v", i+1, c.player, c.command, c.want, got)
+		}
+	}
+}
+
+func TestMultiplayer_Players(t *testing.T) {
+	g := newSharedGame(t, "Kate")
+	if _, err := g.AddPlayer("Kate"); err == nil {
+		t.Error("expected error for duplicate player")
+	}
+	if _, err := g.AddPlayer("два слова"); err == nil {
+		t.Error("expected error for name with spaces")
+	}
+	if _, err := g.AddPlayer("Tristan"); err != nil {
+		t.Fatal(err)
+	}
+	g.RemovePlayer("Tristan")
+	want := []Message{{"Kate", "ты находишься на кухне, на столе: чай, надо собрать рюкзак и идти в универ. можно пройти - коридор"}}
+	if got := g.HandleCommand("Kate", "осмотреться"); !reflect.DeepEqual(got, want) {
+		t.Errorf("removed player is still visible: %v", got)
+	}
+	want = []Message{{"Tristan", "нет такого игрока"}}
+	if got := g.HandleCommand("Tristan", "осмотреться"); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected answer for removed player: %v", got)
+	}
+}
+
+func TestMultiplayer_LeaverDropsItems(t *testing.T) {
+	g := newSharedGame(t, "Kate", "Tristan")
+	for _, command := range []string{"идти коридор", "идти комната", "надеть рюкзак", "взять ключи"} {
+		g.HandleCommand("Kate", command)
+	}
+	g.RemovePlayer("Kate")
+	cases := []struct {
+		command string
+		want    string
+	}{
+		{"идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{"идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{"осмотреться", "на столе: конспекты, на полу: рюкзак. можно пройти - коридор"},
+		{"надеть рюкзак", "вы надели: рюкзак"},
+		// ключи остались в рюкзаке
+		{"инвентарь", "надето: рюкзак, рюкзак: ключи"},
+	}
+	for i, c := range cases {
+		want := []Message{{"Tristan", c.want}}
+		if got := g.HandleCommand("Tristan", c.command); !reflect.DeepEqual(got, want) {
+			t.Errorf("step %d, %q:\n\texpected %v\n\tgot      %v", i+1, c.command, want, got)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NPCDef - персонаж мира. Он стоит в комнате Room, его можно осмотреть
+// и с ним можно поговорить: разговор начинается с реплики Start
+type NPCDef struct {
+	Name        string `json:"name"`
+	Room        string `json:"room"`
+	Description string `json:"description,omitempty"`
+	Start       string `json:"start"`
+	// Dialogue - реплики персонажа по именам
+	Dialogue map[string]DialogueNode `json:"dialogue"`
+}
+
+// DialogueNode - реплика персонажа. Эффекты применяются, когда персонаж ее произносит,
+// а если вариантов ответа нет (или ни один не подходит), разговор на ней заканчивается
+type DialogueNode struct {
+	Text    string   `json:"text"`
+	Effects []Effect `json:"effects,omitempty"`
+	Choices []Choice `json:"choices,omitempty"`
+}
+
+// Choice - вариант ответа игрока. Вариант виден, только если выполнено условие If
+type Choice struct {
+	Text    string     `json:"text"`
+	If      *Condition `json:"if,omitempty"`
+	Effects []Effect   `json:"effects,omitempty"`
+	// Next - следующая реплика персонажа, пустая - разговор окончен
+	Next string `json:"next,omitempty"`
+}
+
+// Dialogue - с кем и на какой реплике сейчас разговаривает игрок
+type Dialogue struct {
+	NPC  string `json:"npc"`
+	Node string `json:"node"`
+}
+
+// npcIn ищет персонажа в комнате
+func (room *Room) npcIn(name string) (*NPCDef, bool) {
+	for i := range room.npcs {
+		if room.npcs[i].Name == name {
+			return &room.npcs[i], true
+		}
+	}
+	return nil, false
+}
+
+func (room *Room) npcNames() []string {
+	names := make([]string, 0, len(room.npcs))
+	for _, npc := range room.npcs {
+		names = append(names, npc.Name)
+	}
+	return names
+}
+
+// examineNPC - что видно, если осмотреть персонажа
+func (g *Game) examineNPC(player *Player, name string) string {
+	npc, ok := player.room.npcIn(name)
+	if !ok {
+		return g.tr(player, "тут нет такого")
+	}
+	if npc.Description == "" {
+		return g.tr(player, "ничего особенного")
+	}
+	return g.local(player, npc.Description)
+}
+
+// talk начинает разговор с персонажем
+func (g *Game) talk(player *Player, name string) (bool, string) {
+	npc, ok := player.room.npcIn(name)
+	if !ok {
+		return false, g.tr(player, "тут нет такого")
+	}
+	return true, g.speak(player, npc, npc.Start)
+}
+
+// answer выбирает вариант ответа по номеру из последней реплики
+func (g *Game) answer(player *Player, number string) (bool, string) {
+	if player.dialogue == nil {
+		return false, g.tr(player, "вы ни с кем не разговариваете")
+	}
+	npc, ok := player.room.npcIn(player.dialogue.NPC)
+	if !ok {
+		player.dialogue = nil
+		return false, g.tr(player, "вы ни с кем не разговариваете")
+	}
+	choices := g.choicesFor(player, npc.Dialogue[player.dialogue.Node])
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 || n > len(choices) {
+		return false, g.tr(player, "нет такого варианта")
+	}
+	choice := choices[n-1]
+	g.apply(choice.Effects, player)
+	if choice.Next == "" {
+		player.dialogue = nil
+		return true, g.tr(player, "разговор окончен")
+	}
+	return true, g.speak(player, npc, choice.Next)
+}
+
+// speak - персонаж произносит реплику: применяются ее эффекты,
+// а игрок видит текст и доступные варианты ответа
+func (g *Game) speak(player *Player, npc *NPCDef, nodeName string) string {
+	node := npc.Dialogue[nodeName]
+	g.apply(node.Effects, player)
+	text := g.local(player, npc.Name) + ": " + g.local(player, node.Text)
+	choices := g.choicesFor(player, node)
+	// разговор заканчивается, если отвечать нечего или эффекты увели игрока от персонажа
+	if _, ok := player.room.npcIn(npc.Name); !ok || len(choices) == 0 {
+		player.dialogue = nil
+		return text
+	}
+	player.dialogue = &Dialogue{NPC: npc.Name, Node: nodeName}
+	options := make([]string, 0, len(choices))
+	for i, choice := range choices {
+		options = append(options, fmt.Sprintf("%d - %s", i+1, g.local(player, choice.Text)))
+	}
+	return text + " (" + strings.Join(options, ", ") + ")"
+}
+
+// choicesFor - варианты ответа, условия которых выполняются для игрока
+func (g *Game) choicesFor(player *Player, node DialogueNode) []Choice {
+	var choices []Choice
+	for _, choice := range node.Choices {
+		if g.check(choice.If, player) {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// validateNPCs проверяет персонажей: комнату, имена и переходы между репликами
+func (w *World) validateNPCs(rc *ruleChecker, addErr func(format string, args ...interface{})) {
+	taken := make(map[string]map[string]bool)
+	for _, r := range w.Rooms {
+		names := make(map[string]bool)
+		for _, obj := range r.Objects {
+			names[obj.Name] = true
+			if obj.Exit != "" {
+				names[obj.Exit] = true
+			}
+		}
+		taken[r.Name] = names
+	}
+	npcs := make(map[string]bool)
+	for _, npc := range w.NPCs {
+		if npc.Name == "" {
+			addErr("персонаж без имени")
+			continue
+		}
+		where := fmt.Sprintf("персонаж %q", npc.Name)
+		if npcs[npc.Name] {
+			addErr("%s описан несколько раз", where)
+		}
+		npcs[npc.Name] = true
+		if names, ok := taken[npc.Room]; !ok {
+			addErr("%s: неизвестная комната %q", where, npc.Room)
+		} else if names[npc.Name] {
+			addErr("%s: имя занято объектом комнаты", where)
+		}
+		if _, ok := npc.Dialogue[npc.Start]; !ok {
+			addErr("%s: нет начальной реплики %q", where, npc.Start)
+		}
+		nodes := make([]string, 0, len(npc.Dialogue))
+		for name := range npc.Dialogue {
+			nodes = append(nodes, name)
+		}
+		sort.Strings(nodes)
+		for _, name := range nodes {
+			node := npc.Dialogue[name]
+			at := fmt.Sprintf("%s, реплика %q", where, name)
+			rc.checkEffects(at, node.Effects)
+			for i, choice := range node.Choices {
+				at := fmt.Sprintf("%s, ответ %d", at, i+1)
+				rc.checkCondition(at, choice.If)
+				rc.checkEffects(at, choice.Effects)
+				if _, ok := npc.Dialogue[choice.Next]; choice.Next != "" && !ok {
+					addErr("%s: нет реплики %q", at, choice.Next)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const yardWorld = `{
+	"start": "двор",
+	"npcs": [
+		{"name": "сторож", "room": "двор", "description": "старик в ватнике", "start": "привет",
+			"dialogue": {
+				"привет": {"text": "чего тебе?", "choices": [
+					{"text": "попросить ключ", "if": {"not": {"has": "ключ"}}, "next": "ключ"},
+					{"text": "открыть ворота", "if": {"has": "ключ"}, "next": "ворота"},
+					{"text": "уйти"}
+				]},
+				"ключ": {"text": "держи, только верни", "effects": [{"give": "ключ"}], "choices": [
+					{"text": "спасибо", "next": "привет"}
+				]},
+				"ворота": {"text": "открыл, проходи", "effects": [{"take": "ключ"}, {"setFlag": "ворота открыты"}]}
+			}}
+	],
+	"rooms": [
+		{"name": "двор", "description": "пустой двор", "items": [], "objects": [
+			{"name": "ворота", "exit": "улица", "actions": [{"type": "go", "to": "улица", "if": {"flag": "ворота открыты"}, "fail": "ворота заперты"}]}
+		]},
+		{"name": "улица", "description": "ты на улице", "items": []}
+	]
+}`
+
+func TestNPC_Dialogue(t *testing.T) {
+	w, err := ParseWorld([]byte(yardWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	playSteps(t, g, []gameCase{
+		{1, "осмотреться", "пустой двор, здесь: сторож. можно пройти - улица"},
+		{2, "осмотреть сторожа", "старик в ватнике"},
+		{3, "ответить 1", "вы ни с кем не разговариваете"},
+		{4, "идти улица", "ворота заперты"},
+		{5, "поговорить со сторожем", "сторож: чего тебе? (1 - попросить ключ, 2 - уйти)"},
+		{6, "3", "нет такого варианта"},
+		{7, "1", "сторож: держи, только верни (1 - спасибо)"},
+		{8, "инвентарь", "в руках: ключ"},
+		{9, "ответить 1", "сторож: чего тебе? (1 - открыть ворота, 2 - уйти)"},
+		{10, "1", "сторож: открыл, проходи"},
+		{11, "1", "неизвестная команда"},
+		{12, "инвентарь", "инвентарь пуст"},
+		{13, "поговори с сторож", "сторож: чего тебе? (1 - попросить ключ, 2 - уйти)"},
+		{14, "2", "разговор окончен"},
+		{15, "идти улица", "ты на улице"},
+		{16, "поговорить с сторож", "тут нет такого"},
+	})
+}
+
+func TestNPC_DialogueSurvivesSnapshot(t *testing.T) {
+	w, err := ParseWorld([]byte(yardWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	g.handleCommand("поговорить с сторож")
+	restored := NewGame(w)
+	if err := restored.Restore(g.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.handleCommand("1"); got != "сторож: держи, только верни (1 - спасибо)" {
+		t.Errorf("dialogue was not restored, got %q", got)
+	}
+}
+
+func TestNPC_Validation(t *testing.T) {
+	data := `{
+		"start": "двор",
+		"npcs": [
+			{"name": "ворота", "room": "двор", "start": "а", "dialogue": {"а": {"text": "а", "choices": [{"text": "б", "next": "б"}]}}},
+			{"name": "дух", "room": "чердак", "start": "нет", "dialogue": {}},
+			{"name": "дух", "room": "двор", "start": "а", "dialogue": {"а": {"text": "а", "effects": [{"move": "подвал"}]}}}
+		],
+		"rooms": [{"name": "двор", "items": [], "objects": [{"name": "ворота", "actions": []}]}]
+	}`
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		`персонаж "ворота": имя занято объектом комнаты`,
+		`персонаж "ворота", реплика "а", ответ 1: нет реплики "б"`,
+		`персонаж "дух": неизвестная комната "чердак"`,
+		`персонаж "дух": нет начальной реплики "нет"`,
+		`персонаж "дух" описан несколько раз`,
+		`персонаж "дух", реплика "а", эффект 1: неизвестная комната "подвал"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q not reported:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+// Состояния объектов: дверь, сундук или ворота бывают заперты, закрыты,
+// открыты или сломаны
+const (
+	StateLocked = "locked"
+	StateClosed = "closed"
+	StateOpen   = "open"
+	StateBroken = "broken"
+)
+
+// stateNames - как состояние называется в ответах игры
+var stateNames = map[string]string{
+	StateLocked: "заперто",
+	StateClosed: "закрыто",
+	StateOpen:   "открыто",
+	StateBroken: "сломано",
+}
+
+// allows - доступно ли действие, когда объект в состоянии state
+func (action Action) allows(state string) bool {
+	if len(action.from) == 0 {
+		return true
+	}
+	for _, from := range action.from {
+		if from == state {
+			return true
+		}
+	}
+	return false
+}
+
+// setState переводит объект комнаты в новое состояние. Состояние хранится
+// и в описании объекта, чтобы попасть в сохранение
+func (room *Room) setState(name, state string) {
+	obj, ok := room.Objects[name]
+	if !ok {
+		return
+	}
+	obj.State = state
+	obj.def.State = state
+	room.Objects[name] = obj
+}
+
+// stateText - в каком состоянии объект, например "дверь: заперто"
+func (g *Game) stateText(player *Player, obj *GameObject) string {
+	if obj.State == "" {
+		return g.tr(player, "ничего не произошло")
+	}
+	return g.local(player, obj.Name) + ": " + g.tr(player, stateNames[obj.State])
+}
+
+// changeState - команды "открыть", "закрыть", "запереть" и "сломать". Что
+// происходит с объектом, описывают его действия open, close, lock и break
+func (g *Game) changeState(player *Player, cmd Command) string {
+	obj, ok := getObject(player.room, cmd.Object)
+	if !ok {
+		return g.reject(player, cmd, g.tr(player, "нет такого"))
+	}
+	response, ok := g.handleObjectAction(player, obj, actionTypes[cmd.Verb], "")
+	if !ok {
+		if response == "" {
+			response = g.tr(player, "так нельзя")
+		}
+		return g.reject(player, cmd, response)
+	}
+	if response == "" {
+		changed := player.room.Objects[obj.Name]
+		return g.stateText(player, &changed)
+	}
+	return response
+}
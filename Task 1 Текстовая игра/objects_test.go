@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ящик можно открыть, закрыть и разбить топором, а ворота открываются,
+// только если сломать замок, - смена состояния другого объекта эффектом
+const workshopWorld = `{
+	"start": "мастерская",
+	"items": [
+		{"name": "сумка", "capacity": 3, "wear": "плечо"},
+		{"name": "топор", "weight": 2}
+	],
+	"rooms": [
+		{"name": "мастерская", "description": "тесная мастерская", "items": ["сумка", "топор"],
+			"notes": [{"if": {"object": "ворота", "state": "open"}, "text": "ворота распахнуты"}],
+			"objects": [
+			{"name": "ящик", "state": "closed", "actions": [
+				{"type": "open", "from": ["closed"], "state": "open"},
+				{"type": "close", "from": ["open"], "state": "closed", "commentary": "ящик закрыт"},
+				{"type": "break", "from": ["closed", "open"], "item": "топор", "state": "broken", "commentary": "ящик разлетелся в щепки"}
+			]},
+			{"name": "замок", "actions": [
+				{"type": "break", "item": "топор", "commentary": "замок сломан, ворота распахнулись",
+					"effects": [{"object": "ворота", "state": "open"}]}
+			]},
+			{"name": "ворота", "exit": "двор", "state": "locked", "actions": [
+				{"type": "go", "to": "двор", "fail": "ворота не поддаются"}
+			]}
+		]},
+		{"name": "двор", "description": "двор", "items": []}
+	]
+}`
+
+func TestObjects_DefaultWorldDoor(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{2, "открыть дверь", "дверь заперта"},
+		{3, "сломать дверь", "так нельзя"},
+		{4, "закрыть дверь", "дверь: заперто"},
+		{5, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{6, "надеть рюкзак", "вы надели: рюкзак"},
+		{7, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{8, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{9, "открыть дверь", "дверь открыта"},
+		{10, "открыть дверь", "дверь: открыто"},
+		{11, "закрыть дверь", "дверь закрыта"},
+		{12, "осмотреть дверь", "входная дверь, закрыта"},
+		{13, "идти улица", "дверь закрыта"},
+		{14, "запереть дверь", "дверь заперта"},
+		{15, "выходы", "выходы: кухня - открыто, комната - открыто, улица - заперто"},
+		{16, "применить ключи дверь", "дверь открыта"},
+		{17, "применить ключи дверь", "дверь: открыто"},
+		{18, "language en", "language: English"},
+		{19, "close the door", "the door is closed"},
+		{20, "unlock door", "the door is open"},
+		{21, "go street", "it is spring outside. exits - home"},
+	})
+}
+
+func TestObjects_BreakAndRemoteState(t *testing.T) {
+	w, err := ParseWorld([]byte(workshopWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	playSteps(t, g, []gameCase{
+		{1, "открыть ящик", "ящик: открыто"},
+		{2, "закрыть ящик", "ящик закрыт"},
+		{3, "идти двор", "ворота не поддаются"},
+		{4, "выходы", "выходы: двор - заперто"},
+		{5, "сломать замок", "нужен предмет - топор"},
+		{6, "открыть замок", "так нельзя"},
+		{7, "взять сумку", "вы надели: сумка"},
+		{8, "взять топор", "предмет добавлен в инвентарь: топор"},
+		{9, "сломать замок", "замок сломан, ворота распахнулись"},
+		{10, "осмотреться", "тесная мастерская, ворота распахнуты. можно пройти - двор"},
+		{11, "сломать ящик", "ящик разлетелся в щепки"},
+		{12, "открыть ящик", "ящик: сломано"},
+		{13, "осмотреть ящик", "ящик: сломано"},
+		{14, "осмотреть ворота", "можно пройти - двор"},
+		{15, "идти двор", "двор"},
+	})
+	report := Check(w)
+	if len(report.UnreachableRooms) != 0 {
+		t.Errorf("yard must be reachable: %s", report)
+	}
+}
+
+func TestObjects_StateIsSavedAndUndone(t *testing.T) {
+	w, err := ParseWorld([]byte(workshopWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	g.handleCommand("открыть ящик")
+	s := g.Snapshot()
+	if got := s.Rooms[0].Objects[0].State; got != StateOpen {
+		t.Errorf("saved state must be open, got %q", got)
+	}
+	if got := g.handleCommand("отменить"); got != "отменено: открыть ящик" {
+		t.Errorf("unexpected undo reply %q", got)
+	}
+	if got := g.handleCommand("осмотреть ящик"); got != "ящик: закрыто" {
+		t.Errorf("undo must close the box, got %q", got)
+	}
+
+	restored := NewGame(w)
+	if err := restored.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.handleCommand("закрыть ящик"); got != "ящик закрыт" {
+		t.Errorf("restored box must be open, got %q", got)
+	}
+}
+
+func TestObjects_Validation(t *testing.T) {
+	data := strings.Replace(workshopWorld, `"state": "closed", "actions"`, `"state": "ajar", "actions"`, 1)
+	data = strings.Replace(data, `{"type": "break", "item": "топор"`, `{"type": "break", "item": "пила", "state": "open"`, 1)
+	data = strings.Replace(data, `{"object": "ворота", "state": "open"}]`, `{"object": "ворота"}]`, 1)
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected state errors")
+	}
+	for _, want := range []string{
+		`объект "ящик": неизвестное состояние "ajar"`,
+		`объект "замок": требуется неизвестный предмет "пила"`,
+		`объект "замок": у объекта нет состояния, а действие "break" его использует`,
+		`эффект 1: для смены состояния нужны и объект, и состояние`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error %q in\n%v", want, err)
+		}
+	}
+}
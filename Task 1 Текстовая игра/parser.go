@@ -0,0 +1,328 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Command - команда игрока после разбора
+type Command struct {
+	// Verb - действие из actionsAliases ("take", "go", ...), пустое - команда не распознана
+	Verb string
+	// Object - предмет или объект, над которым совершается действие
+	Object string
+	// Target - второй участник: контейнер, объект, игрок или место, откуда берут
+	Target string
+	// Text - свободный текст: реплика, имя сохранения
+	Text string
+	// Suggestions - на что похож нераспознанный глагол или имя, если вариантов несколько
+	Suggestions []string
+}
+
+// token - слово команды или имя в кавычках целиком
+type token struct {
+	text   string
+	quoted bool
+	// где слово заканчивается в исходной строке, чтобы отрезать свободный текст как есть
+	end int
+}
+
+// fillers - слова, которые не влияют на смысл команды
+var fillers = map[string]bool{
+	"пожалуйста": true,
+	"ну":         true,
+	"же":         true,
+	"ка":         true,
+	"давай":      true,
+	"мне":        true,
+	"себе":       true,
+	"тот":        true,
+	"ту":         true,
+	"те":         true,
+	"этот":       true,
+	"эту":        true,
+	"это":        true,
+	"эти":        true,
+}
+
+// prepositions - предлоги, которые отделяют второй участник действия
+var prepositions = map[string][]string{
+	"take":    {"из", "изо", "с", "со", "у", "от"},
+	"takeout": {"из", "изо", "с", "со"},
+	"put":     {"в", "во", "на"},
+	"use":     {"к", "ко", "на", "в", "во", "для"},
+	"give":    {"для"},
+	"combine": {"и", "с", "со"},
+}
+
+// leading - предлоги перед единственным участником действия:
+// "идти в коридор", "иди на улицу", "поговорить со сторожем"
+var leading = map[string][]string{
+	"go":   {"в", "во", "на", "к", "ко"},
+	"talk": {"с", "со"},
+}
+
+// endings - окончания, которые отбрасываются при сравнении имен,
+// чтобы "ключами", "двери" и "на улицу" находили "ключи", "дверь" и "улица"
+var endings = []string{
+	"ями", "ами", "ого", "его", "ому", "ему", "ыми", "ими",
+	"ой", "ей", "ую", "юю", "ая", "яя", "ое", "ее", "ые", "ие", "ых", "их",
+	"ом", "ем", "ам", "ям", "ах", "ях", "ью", "ов", "ев",
+	"а", "я", "о", "е", "у", "ю", "ы", "и", "ь", "й",
+}
+
+// tokenize разбивает строку на слова. Имя в кавычках ("..." или «...») - одно слово,
+// знаки препинания на концах слов и частица "-ка" отбрасываются
+func tokenize(input string) []token {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case r == ' ' || r == '\t':
+			i += size
+		case r == '"' || r == '«':
+			closing := "\""
+			if r == '«' {
+				closing = "»"
+			}
+			start := i + size
+			end := strings.Index(input[start:], closing)
+			if end < 0 {
+				tokens = append(tokens, token{text: strings.TrimSpace(input[start:]), quoted: true, end: len(input)})
+				return tokens
+			}
+			tokens = append(tokens, token{text: strings.TrimSpace(input[start : start+end]), quoted: true, end: start + end + len(closing)})
+			i = start + end + len(closing)
+		default:
+			end := strings.IndexAny(input[i:], " \t")
+			if end < 0 {
+				end = len(input)
+			} else {
+				end += i
+			}
+			word := strings.Trim(input[i:end], ".,!?;:")
+			word = strings.TrimSuffix(word, "-ка")
+			if word != "" {
+				tokens = append(tokens, token{text: word, end: end})
+			}
+			i = end
+		}
+	}
+	return tokens
+}
+
+// normalize приводит слово к виду для сравнения: строчные буквы, е вместо ё
+func normalize(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+}
+
+// stem отбрасывает окончание, оставляя хотя бы две буквы основы
+func (loc *Locale) stem(word string) string {
+	word = normalize(word)
+	for _, ending := range loc.Endings {
+		if strings.HasSuffix(word, ending) && utf8.RuneCountInString(word)-utf8.RuneCountInString(ending) >= 2 {
+			return strings.TrimSuffix(word, ending)
+		}
+	}
+	return word
+}
+
+// resolve ищет среди известных имен то, которое называют слова команды.
+// Если такого нет, возвращает слова как есть, чтобы игра ответила про них
+func (loc *Locale) resolve(words []token, known []string) (string, bool) {
+	texts := make([]string, 0, len(words))
+	for _, w := range words {
+		texts = append(texts, w.text)
+	}
+	phrase := strings.Join(texts, " ")
+	if len(words) == 1 && words[0].quoted {
+		return phrase, containsName(known, phrase)
+	}
+	for _, name := range known {
+		if normalize(name) == normalize(phrase) {
+			return name, true
+		}
+	}
+	for _, name := range known {
+		parts := strings.Fields(name)
+		if len(parts) != len(words) {
+			continue
+		}
+		matched := true
+		for i := range parts {
+			if loc.stem(parts[i]) != loc.stem(words[i].text) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return name, true
+		}
+	}
+	return phrase, false
+}
+
+// resolveTypo - то же, что resolve, но прощает опечатки, если имя угадывается однозначно
+func (loc *Locale) resolveTypo(words []token, known []string) (string, bool) {
+	phrase, ok := loc.resolve(words, known)
+	if ok || (len(words) == 1 && words[0].quoted) {
+		return phrase, ok
+	}
+	if name, _ := loc.guessName(phrase, known); name != "" {
+		return name, true
+	}
+	return phrase, false
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCommand разбирает ввод игрока на языке loc. known - имена, которые игрок
+// может назвать: предметы, объекты, выходы и другие игроки
+func (loc *Locale) parseCommand(input string, known []string) Command {
+	cmd := loc.parseWords(input, known)
+	// несколько похожих имен - игра предложит выбрать из них
+	for _, name := range []string{cmd.Object, cmd.Target} {
+		if name == "" || containsName(known, name) {
+			continue
+		}
+		if _, found := loc.guessName(name, known); len(found) > 1 {
+			cmd.Suggestions = found
+			break
+		}
+	}
+	return cmd
+}
+
+func (loc *Locale) parseWords(input string, known []string) Command {
+	tokens := tokenize(input)
+
+	// глагол может состоять из нескольких слов: "выйти из игры"
+	var cmd Command
+	verbLen := 0
+	for n := 3; n > 0; n-- {
+		if len(tokens) < n {
+			continue
+		}
+		words := make([]string, 0, n)
+		for _, t := range tokens[:n] {
+			words = append(words, normalize(t.text))
+		}
+		if verb, ok := loc.Verbs[strings.Join(words, " ")]; ok && !tokens[0].quoted {
+			cmd.Verb = verb
+			verbLen = n
+			break
+		}
+	}
+	if cmd.Verb == "" {
+		if len(tokens) > 0 && !tokens[0].quoted {
+			cmd.Verb, cmd.Suggestions = loc.guessVerb(normalize(tokens[0].text))
+			verbLen = 1
+		}
+		if cmd.Verb == "" {
+			return cmd
+		}
+	}
+	rest := tokens[verbLen:]
+
+	// реплики и имена сохранений передаются как есть
+	switch cmd.Verb {
+	case "say":
+		cmd.Text = strings.TrimSpace(input[tokens[verbLen-1].end:])
+		return cmd
+	case "whisper":
+		if len(rest) > 0 {
+			cmd.Target, _ = loc.resolveTypo(rest[:1], known)
+			cmd.Text = strings.TrimSpace(input[rest[0].end:])
+		}
+		return cmd
+	case "save", "load", "locale":
+		if len(rest) > 0 {
+			cmd.Text = rest[0].text
+		}
+		return cmd
+	}
+
+	words := rest[:0:0]
+	for _, t := range rest {
+		if !t.quoted && loc.Fillers[normalize(t.text)] {
+			continue
+		}
+		words = append(words, t)
+	}
+	if len(words) > 1 && !words[0].quoted && containsName(loc.Leading[cmd.Verb], normalize(words[0].text)) {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return cmd
+	}
+
+	// имя целиком может само содержать предлог: "ключи от двери"
+	if name, ok := loc.resolve(words, known); ok {
+		cmd.Object = name
+		return cmd
+	}
+	for i, w := range words {
+		if i == 0 || w.quoted || !containsName(loc.Prepositions[cmd.Verb], normalize(w.text)) {
+			continue
+		}
+		cmd.Object, _ = loc.resolveTypo(words[:i], known)
+		if i+1 < len(words) {
+			cmd.Target, _ = loc.resolveTypo(words[i+1:], known)
+		}
+		return cmd
+	}
+
+	if cmd.Verb == "use" || cmd.Verb == "give" || cmd.Verb == "combine" {
+		cmd.Object, cmd.Target = loc.splitPair(words, known)
+		return cmd
+	}
+	cmd.Object, _ = loc.resolveTypo(words, known)
+	return cmd
+}
+
+// splitPair делит слова без предлога на два имени: "применить ключи дверь".
+// Предпочитается разбиение, при котором известны оба имени, сначала без опечаток
+func (loc *Locale) splitPair(words []token, known []string) (string, string) {
+	if len(words) == 1 {
+		object, _ := loc.resolveTypo(words, known)
+		return object, ""
+	}
+	for _, match := range []func([]token, []string) (string, bool){loc.resolve, loc.resolveTypo} {
+		for i := 1; i < len(words); i++ {
+			object, okObject := match(words[:i], known)
+			target, okTarget := match(words[i:], known)
+			if okObject && okTarget {
+				return object, target
+			}
+		}
+	}
+	object, _ := loc.resolveTypo(words[:1], known)
+	target, _ := loc.resolveTypo(words[1:], known)
+	return object, target
+}
+
+// knownNames - все имена, которые игрок может назвать в команде
+func (g *Game) knownNames(player *Player) []string {
+	room := player.room
+	names := append(player.allItems(), room.allItems()...)
+	for _, name := range room.objectOrder {
+		obj, ok := room.Objects[name]
+		if !ok {
+			continue
+		}
+		names = append(names, obj.Name)
+		if obj.Exit != "" {
+			names = append(names, obj.Exit)
+		}
+	}
+	names = append(names, room.npcNames()...)
+	return append(names, g.order...)
+}
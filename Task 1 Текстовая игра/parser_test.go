@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	known := []string{"ключи", "дверь", "улица", "рюкзак", "старый ключ", "ключи от двери", "Tristan"}
+	cases := []struct {
+		input string
+		want  Command
+	}{
+		{"взять ключи", Command{Verb: "take", Object: "ключи"}},
+		{"  возьми-ка   ключи  ", Command{Verb: "take", Object: "ключи"}},
+		{"возьми, пожалуйста, ключи со стола", Command{Verb: "take", Object: "ключи", Target: "стола"}},
+		{"достань ключи из рюкзака", Command{Verb: "takeout", Object: "ключи", Target: "рюкзак"}},
+		{"иди на улицу", Command{Verb: "go", Object: "улица"}},
+		{"идти комната", Command{Verb: "go", Object: "комната"}},
+		{"примени ключи к двери", Command{Verb: "use", Object: "ключи", Target: "дверь"}},
+		{"применить ключи дверь", Command{Verb: "use", Object: "ключи", Target: "дверь"}},
+		{"используй старый ключ дверь", Command{Verb: "use", Object: "старый ключ", Target: "дверь"}},
+		{"возьми старый ключ", Command{Verb: "take", Object: "старый ключ"}},
+		{"возьми ключи от двери", Command{Verb: "take", Object: "ключи от двери"}},
+		{`возьми "старый ключ"`, Command{Verb: "take", Object: "старый ключ"}},
+		{"возьми «старый  ключ»", Command{Verb: "take", Object: "старый  ключ"}},
+		{"положи ключи в рюкзак", Command{Verb: "put", Object: "ключи", Target: "рюкзак"}},
+		{"отдай ключи tristan", Command{Verb: "give", Object: "ключи", Target: "Tristan"}},
+		{"скажи  Привет,  всем!", Command{Verb: "say", Text: "Привет,  всем!"}},
+		{"сказать_игроку Tristan как дела?", Command{Verb: "whisper", Target: "Tristan", Text: "как дела?"}},
+		{"сохранить утро", Command{Verb: "save", Text: "утро"}},
+		{"Выйти из игры", Command{Verb: "exit"}},
+		{"завтракать", Command{}},
+		{"возми клюси", Command{Verb: "take", Object: "ключи"}},
+		{"отдай ключи тристан", Command{Verb: "give", Object: "ключи", Target: "тристан"}},
+		{"возьми клю", Command{Verb: "take", Object: "клю", Suggestions: []string{"ключи", "ключи от двери"}}},
+	}
+	for _, c := range cases {
+		if got := russian.parseCommand(c.input, known); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: expected %+v got %+v", c.input, c.want, got)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"ключами", "ключи"},
+		{"двери", "дверь"},
+		{"улицу", "улица"},
+		{"рюкзаке", "рюкзак"},
+		{"конспектов", "конспекты"},
+		{"Ёлку", "елка"},
+	} {
+		if russian.stem(pair[0]) != russian.stem(pair[1]) {
+			t.Errorf("%q and %q should have the same stem: %q, %q", pair[0], pair[1], russian.stem(pair[0]), russian.stem(pair[1]))
+		}
+	}
+}
+
+func TestGame_NaturalCommands(t *testing.T) {
+	g := NewGame(testWorld(t))
+	playSteps(t, g, []gameCase{
+		{1, "", "Введите команду"},
+		{2, "иди в коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{3, "пойди в комнату", "ты в своей комнате. можно пройти - коридор"},
+		{4, "надень рюкзак", "вы надели: рюкзак"},
+		{5, "возьми ключи со стола", "предмет добавлен в инвентарь: ключи"},
+		{6, "возьми, пожалуйста, конспекты!", "предмет добавлен в инвентарь: конспекты"},
+		{7, "иди в коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{8, "примени ключи к двери", "дверь открыта"},
+		{9, "иди на улицу", "на улице весна. можно пройти - домой"},
+		{10, "выйти из игры", "Спасибо за игру!"},
+	})
+}
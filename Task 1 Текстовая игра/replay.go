@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// TranscriptStep - команда записанной сессии и ответ, который игра должна на нее дать
+type TranscriptStep struct {
+	Command string
+	Expect  string
+	// Line - строка файла с командой, чтобы автор квеста сразу нашел место
+	Line int
+}
+
+// Transcript - записанная сессия. В файле она выглядит так же, как в консоли:
+//
+//	# комментарий
+//	> идти коридор
+//	ничего интересного. можно пройти - кухня, комната, улица
+//
+// Ответ может занимать несколько строк подряд, как карта
+type Transcript []TranscriptStep
+
+// ParseTranscript читает сессию. После каждой команды ("> ...") должен идти ответ:
+// строки подряд до пустой строки, комментария или следующей команды.
+// Пустые строки и комментарии пропускаются
+func ParseTranscript(r io.Reader) (Transcript, error) {
+	var t Transcript
+	lines := bufio.NewScanner(r)
+	n := 0
+	waiting := false
+	// answering - предыдущая строка была ответом, следующая продолжает его
+	answering := false
+	for lines.Scan() {
+		n++
+		line := strings.TrimRight(lines.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			answering = false
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			if waiting {
+				return nil, fmt.Errorf("строка %d: нет ответа на команду %q", t[len(t)-1].Line, t[len(t)-1].Command)
+			}
+			t = append(t, TranscriptStep{Command: strings.TrimSpace(line[1:]), Line: n})
+			waiting = true
+			answering = false
+			continue
+		}
+		if answering {
+			t[len(t)-1].Expect += "\n" + line
+			continue
+		}
+		if !waiting {
+			return nil, fmt.Errorf("строка %d: ответ без команды", n)
+		}
+		t[len(t)-1].Expect = line
+		waiting = false
+		answering = true
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	if waiting {
+		return nil, fmt.Errorf("строка %d: нет ответа на команду %q", t[len(t)-1].Line, t[len(t)-1].Command)
+	}
+	return t, nil
+}
+
+// LoadTranscript читает сессию из файла
+func LoadTranscript(path string) (Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTranscript(f)
+}
+
+// WriteStep дописывает в сессию команду и ответ игры
+func WriteStep(w io.Writer, command, reply string) error {
+	_, err := fmt.Fprintf(w, "> %s\n%s\n", command, reply)
+	return err
+}
+
+// Divergence - первое место, где игра ответила не так, как записано,
+// и состояние мира после этой команды
+type Divergence struct {
+	// Index - номер шага сессии, начиная с 1
+	Index int
+	TranscriptStep
+	Got      string
+	State    State
+	Snapshot Snapshot
+}
+
+func (d *Divergence) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "шаг %d, строка %d: > %s\n", d.Index, d.Line, d.Command)
+	fmt.Fprintf(&b, "ожидалось: %s\n", d.Expect)
+	fmt.Fprintf(&b, "получено:  %s\n", d.Got)
+	fmt.Fprintf(&b, "комната: %s\n", d.State.Room)
+	fmt.Fprintf(&b, "инвентарь: %s\n", listOrDash(d.State.Inventory))
+	fmt.Fprintf(&b, "выходы: %s\n", listOrDash(d.State.Exits))
+	// в состоянии комната названа на языке игрока, а в снимке - как в мире
+	for _, room := range d.Snapshot.Rooms {
+		if room.Name == d.Snapshot.Player.Room {
+			fmt.Fprintf(&b, "предметы в комнате: %s\n", listOrDash(room.Items))
+			fmt.Fprintf(&b, "флаги комнаты: %s\n", listOrDash(room.Flags))
+		}
+	}
+	counters := make([]string, 0, len(d.Snapshot.Counters))
+	for name, value := range d.Snapshot.Counters {
+		counters = append(counters, fmt.Sprintf("%s=%d", name, value))
+	}
+	sort.Strings(counters)
+	fmt.Fprintf(&b, "счетчики: %s", listOrDash(counters))
+	return b.String()
+}
+
+func listOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
+
+// Replay проигрывает сессию в новой игре по миру w на языке locale, с которым
+// ее записывали (пустой - язык по умолчанию), и возвращает первое расхождение
+// с записью или nil, если все ответы совпали
+func Replay(w *World, t Transcript, locale string) *Divergence {
+	g := NewGame(w)
+	if locale != "" {
+		g.SetLocale(locale)
+	}
+	for i, step := range t {
+		got, state := g.Play(step.Command)
+		if got != step.Expect {
+			return &Divergence{Index: i + 1, TranscriptStep: step, Got: got, State: state, Snapshot: g.Snapshot()}
+		}
+	}
+	return nil
+}
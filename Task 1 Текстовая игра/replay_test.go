@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReplay_CourseTranscript(t *testing.T) {
+	transcript, err := LoadTranscript("testdata/course.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transcript) != 9 {
+		t.Fatalf("expected 9 steps, got %d", len(transcript))
+	}
+	if d := Replay(testWorld(t), transcript, ""); d != nil {
+		t.Fatalf("unexpected divergence:\n%v", d)
+	}
+}
+
+func TestReplay_ReportsFirstDivergence(t *testing.T) {
+	var buf bytes.Buffer
+	for _, step := range gameCases[0][:8] {
+		WriteStep(&buf, step.command, step.answer)
+	}
+	// дверь еще не открыта
+	WriteStep(&buf, "идти улица", "на улице весна. можно пройти - домой")
+	WriteStep(&buf, "осмотреться", "неважно")
+
+	transcript, err := ParseTranscript(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Replay(testWorld(t), transcript, "")
+	if d == nil {
+		t.Fatal("divergence not detected")
+	}
+	if d.Index != 9 || d.Line != 17 || d.Got != "дверь закрыта" {
+		t.Errorf("wrong divergence: step %d, line %d, got %q", d.Index, d.Line, d.Got)
+	}
+	report := d.Error()
+	for _, want := range []string{
+		"шаг 9, строка 17: > идти улица",
+		"получено:  дверь закрыта",
+		"комната: коридор",
+		"инвентарь: рюкзак, ключи, конспекты",
+		"флаги комнаты: -",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestReplay_EnglishDivergence(t *testing.T) {
+	var buf bytes.Buffer
+	WriteStep(&buf, "go hallway", "nothing interesting. exits - kitchen, room, street")
+	WriteStep(&buf, "go street", "it is spring outside. exits - home")
+	transcript, err := ParseTranscript(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Replay(testWorld(t), transcript, "en")
+	if d == nil || d.Index != 2 || d.Got != "the door is closed" {
+		t.Fatalf("wrong divergence: %+v", d)
+	}
+	report := d.Error()
+	for _, want := range []string{
+		"комната: hallway",
+		"предметы в комнате: -",
+		"флаги комнаты: -",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestParseTranscript_Errors(t *testing.T) {
+	cases := map[string]string{
+		"> осмотреться\n> идти коридор\nответ\n": "строка 1: нет ответа",
+		"ответ\n": "строка 1: ответ без команды",
+		"# комментарий\n\n> осмотреться\n": "строка 3: нет ответа",
+	}
+	for input, want := range cases {
+		_, err := ParseTranscript(strings.NewReader(input))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error %q, got %v", input, want, err)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Condition - условие, при котором срабатывает действие. Все заданные в нем
+// проверки должны выполняться одновременно, All/Any/Not позволяют собирать
+// из условий более сложные выражения.
+type Condition struct {
+	// Has - у игрока есть предмет
+	Has string `json:"has,omitempty"`
+	// At - игрок находится в комнате
+	At string `json:"at,omitempty"`
+	// Flag - в комнате установлен флаг
+	Flag string `json:"flag,omitempty"`
+	// Item - предмет лежит в комнате
+	Item string `json:"item,omitempty"`
+	// Room - комната для Flag и Item, по умолчанию та, где стоит игрок
+	Room string `json:"room,omitempty"`
+	// Counter - значение счетчика не меньше AtLeast
+	Counter string `json:"counter,omitempty"`
+	AtLeast int    `json:"atLeast,omitempty"`
+	// Object - объект комнаты находится в состоянии State
+	Object string `json:"object,omitempty"`
+	State  string `json:"state,omitempty"`
+	// Time - сейчас такая часть суток, Turn - прошло не меньше стольких ходов
+	Time string `json:"time,omitempty"`
+	Turn int    `json:"turn,omitempty"`
+
+	All []Condition `json:"all,omitempty"`
+	Any []Condition `json:"any,omitempty"`
+	Not *Condition  `json:"not,omitempty"`
+}
+
+// Effect - изменение мира после успешного действия. Заданные поля
+// применяются в порядке их объявления.
+type Effect struct {
+	// Room - комната, к которой относятся флаги, замена объекта и описание,
+	// по умолчанию та, где стоит игрок
+	Room string `json:"room,omitempty"`
+
+	SetFlag   string `json:"setFlag,omitempty"`
+	ClearFlag string `json:"clearFlag,omitempty"`
+	// Swap - заменить объект комнаты с тем же именем на новый
+	Swap *ObjectDef `json:"swap,omitempty"`
+	// Object перевести в состояние State
+	Object string `json:"object,omitempty"`
+	State  string `json:"state,omitempty"`
+	// Give и Take - выдать предмет игроку и забрать у него
+	Give string `json:"give,omitempty"`
+	Take string `json:"take,omitempty"`
+	// Describe - новое описание комнаты
+	Describe string `json:"describe,omitempty"`
+	// Counter увеличивается на Add (Add может быть отрицательным)
+	Counter string `json:"counter,omitempty"`
+	Add     int    `json:"add,omitempty"`
+	// Move - переместить игрока в комнату
+	Move string `json:"move,omitempty"`
+	// Schedule - отложить эффекты на несколько ходов
+	Schedule *Timer `json:"schedule,omitempty"`
+	// Reveal - показать предмет, спрятанный в комнате
+	Reveal string `json:"reveal,omitempty"`
+}
+
+// roomFor - комната, к которой относится условие или эффект
+func (g *Game) roomFor(name string, player *Player) *Room {
+	if name == "" {
+		return player.room
+	}
+	return g.rooms[name]
+}
+
+// check проверяет условие для игрока. Пустое условие выполняется всегда
+func (g *Game) check(c *Condition, player *Player) bool {
+	if c == nil {
+		return true
+	}
+	room := g.roomFor(c.Room, player)
+	if c.Has != "" && !player.hasItem(c.Has) {
+		return false
+	}
+	if c.At != "" && player.room.Name != c.At {
+		return false
+	}
+	if c.Flag != "" && !room.Flags[c.Flag] {
+		return false
+	}
+	if c.Item != "" && !room.hasItem(c.Item) {
+		return false
+	}
+	if c.Object != "" && room.Objects[c.Object].State != c.State {
+		return false
+	}
+	if c.Counter != "" && g.counters[c.Counter] < c.AtLeast {
+		return false
+	}
+	if c.Time != "" && g.period() != c.Time {
+		return false
+	}
+	if g.turn < c.Turn {
+		return false
+	}
+	for i := range c.All {
+		if !g.check(&c.All[i], player) {
+			return false
+		}
+	}
+	if len(c.Any) > 0 {
+		matched := false
+		for i := range c.Any {
+			if g.check(&c.Any[i], player) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if c.Not != nil && g.check(c.Not, player) {
+		return false
+	}
+	return true
+}
+
+// apply применяет эффекты по порядку
+func (g *Game) apply(effects []Effect, player *Player) {
+	for _, e := range effects {
+		room := g.roomFor(e.Room, player)
+		if e.SetFlag != "" {
+			room.Flags[e.SetFlag] = true
+		}
+		if e.ClearFlag != "" {
+			delete(room.Flags, e.ClearFlag)
+		}
+		if e.Swap != nil {
+			if _, ok := room.Objects[e.Swap.Name]; !ok {
+				room.objectOrder = append(room.objectOrder, e.Swap.Name)
+			}
+			room.Objects[e.Swap.Name] = newObject(*e.Swap)
+		}
+		if e.Object != "" {
+			room.setState(e.Object, e.State)
+		}
+		if e.Give != "" && !player.hasItem(e.Give) {
+			// выданный предмет убирается как обычно, а если места нет - остается в руках
+			container, _ := g.placeFor(&player.Inventory, e.Give, g.world.item(e.Give).Weight, "")
+			player.attach(e.Give, container, nil)
+		}
+		if e.Take != "" {
+			player.removeItem(e.Take)
+		}
+		if e.Describe != "" {
+			room.Description = e.Describe
+		}
+		if e.Counter != "" {
+			g.counters[e.Counter] += e.Add
+		}
+		if e.Move != "" && g.rooms[e.Move] != player.room {
+			from := player.room.Name
+			player.room = g.rooms[e.Move]
+			player.visit(player.room)
+			g.emit(player, Event{Kind: RoomEntered, From: from})
+		}
+		if e.Schedule != nil {
+			g.schedule(*e.Schedule, player)
+		}
+		if e.Reveal != "" {
+			room.reveal(e.Reveal)
+		}
+	}
+}
+
+// ruleChecker проверяет ссылки в условиях и эффектах на комнаты, предметы,
+// флаги и счетчики мира
+type ruleChecker struct {
+	rooms    map[string]bool
+	items    map[string]bool
+	flags    map[string]bool
+	counters map[string]bool
+	periods  map[string]bool
+	addErr   func(format string, args ...interface{})
+}
+
+func (rc *ruleChecker) checkRoom(where, room string) {
+	if room != "" && !rc.rooms[room] {
+		rc.addErr("%s: неизвестная комната %q", where, room)
+	}
+}
+
+func (rc *ruleChecker) checkItem(where, item string) {
+	if item != "" && !rc.items[item] {
+		rc.addErr("%s: неизвестный предмет %q", where, item)
+	}
+}
+
+func (rc *ruleChecker) checkState(where, state string) {
+	if state != "" && stateNames[state] == "" {
+		rc.addErr("%s: неизвестное состояние %q", where, state)
+	}
+}
+
+func (rc *ruleChecker) checkCondition(where string, c *Condition) {
+	if c == nil {
+		return
+	}
+	rc.checkRoom(where, c.Room)
+	rc.checkRoom(where, c.At)
+	rc.checkItem(where, c.Has)
+	rc.checkItem(where, c.Item)
+	if c.Flag != "" && !rc.flags[c.Flag] {
+		rc.addErr("%s: флаг %q нигде не устанавливается", where, c.Flag)
+	}
+	if c.Counter != "" && !rc.counters[c.Counter] {
+		rc.addErr("%s: счетчик %q нигде не меняется", where, c.Counter)
+	}
+	if (c.Object == "") != (c.State == "") {
+		rc.addErr("%s: в условии на состояние нужны и объект, и состояние", where)
+	}
+	rc.checkState(where, c.State)
+	if c.Time != "" && !rc.periods[c.Time] {
+		rc.addErr("%s: неизвестная часть суток %q", where, c.Time)
+	}
+	for i := range c.All {
+		rc.checkCondition(where, &c.All[i])
+	}
+	for i := range c.Any {
+		rc.checkCondition(where, &c.Any[i])
+	}
+	rc.checkCondition(where, c.Not)
+}
+
+func (rc *ruleChecker) checkEffects(where string, effects []Effect) {
+	for i, e := range effects {
+		at := fmt.Sprintf("%s, эффект %d", where, i+1)
+		rc.checkRoom(at, e.Room)
+		rc.checkRoom(at, e.Move)
+		rc.checkItem(at, e.Take)
+		rc.checkItem(at, e.Reveal)
+		if (e.Object == "") != (e.State == "") {
+			rc.addErr("%s: для смены состояния нужны и объект, и состояние", at)
+		}
+		rc.checkState(at, e.State)
+		if e.Swap != nil {
+			rc.checkObject(at, *e.Swap)
+		}
+		if e.Schedule != nil {
+			if e.Schedule.In <= 0 {
+				rc.addErr("%s: таймер должен срабатывать хотя бы через ход", at)
+			}
+			rc.checkEffects(at+", таймер", e.Schedule.Effects)
+		}
+	}
+}
+
+// rules собирает все, на что в уже проверенном мире могут ссылаться условия
+// и эффекты: комнаты, предметы, флаги, счетчики и части суток
+func (w *World) rules(addErr func(format string, args ...interface{})) *ruleChecker {
+	rc := &ruleChecker{
+		rooms:    make(map[string]bool),
+		items:    make(map[string]bool),
+		flags:    make(map[string]bool),
+		counters: make(map[string]bool),
+		periods:  make(map[string]bool),
+		addErr:   addErr,
+	}
+	for _, r := range w.Rooms {
+		rc.rooms[r.Name] = true
+		for _, flag := range r.Flags {
+			rc.flags[flag] = true
+		}
+		for _, obj := range r.Objects {
+			rc.collectRules(obj)
+		}
+	}
+	for _, npc := range w.NPCs {
+		for _, node := range npc.Dialogue {
+			rc.collectEffects(node.Effects)
+			for _, choice := range node.Choices {
+				rc.collectEffects(choice.Effects)
+			}
+		}
+	}
+	for _, e := range w.Events {
+		rc.collectEffects(e.Effects)
+	}
+	for _, item := range worldItems(w) {
+		rc.items[item] = true
+	}
+	for _, r := range w.Recipes {
+		rc.items[r.Result] = true
+	}
+	if w.Clock != nil {
+		for _, p := range w.Clock.Periods {
+			rc.periods[p.Name] = true
+		}
+	}
+	return rc
+}
+
+// checkObject проверяет объект комнаты: его состояние, действия, их условия и эффекты
+func (rc *ruleChecker) checkObject(where string, obj ObjectDef) {
+	if obj.Name == "" {
+		rc.addErr("%s: объект без имени", where)
+		return
+	}
+	where = fmt.Sprintf("%s, объект %q", where, obj.Name)
+	rc.checkState(where, obj.State)
+	for _, a := range obj.Actions {
+		actionType, ok := actionTypes[a.Type]
+		if !ok {
+			rc.addErr("%s: неизвестное действие %q", where, a.Type)
+			continue
+		}
+		switch actionType {
+		case ActionGo:
+			if a.To == "" {
+				rc.addErr("%s: не указано, куда ведет переход", where)
+			} else if !rc.rooms[a.To] {
+				rc.addErr("%s: переход в неизвестную комнату %q", where, a.To)
+			}
+		case ActionUse:
+			if a.Item == "" {
+				rc.addErr("%s: не указано, какой предмет применять", where)
+			} else if !rc.items[a.Item] {
+				rc.addErr("%s: требуется неизвестный предмет %q", where, a.Item)
+			}
+		case ActionOpen, ActionClose, ActionLock, ActionBreak:
+			if a.Item != "" && !rc.items[a.Item] {
+				rc.addErr("%s: требуется неизвестный предмет %q", where, a.Item)
+			}
+		}
+		if (len(a.From) > 0 || a.State != "") && obj.State == "" {
+			rc.addErr("%s: у объекта нет состояния, а действие %q его использует", where, a.Type)
+		}
+		for _, state := range a.From {
+			rc.checkState(where, state)
+		}
+		rc.checkState(where, a.State)
+		rc.checkCondition(where, a.If)
+		rc.checkEffects(where, a.Effects)
+	}
+}
+
+// collectRules собирает флаги, счетчики и выдаваемые эффектами предметы,
+// чтобы условия могли на них ссылаться независимо от порядка описания
+func (rc *ruleChecker) collectRules(obj ObjectDef) {
+	for _, a := range obj.Actions {
+		rc.collectEffects(a.Effects)
+	}
+}
+
+func (rc *ruleChecker) collectEffects(effects []Effect) {
+	for _, e := range effects {
+		if e.SetFlag != "" {
+			rc.flags[e.SetFlag] = true
+		}
+		if e.Counter != "" {
+			rc.counters[e.Counter] = true
+		}
+		if e.Give != "" {
+			rc.items[e.Give] = true
+		}
+		if e.Swap != nil {
+			rc.collectRules(*e.Swap)
+		}
+		if e.Schedule != nil {
+			rc.collectEffects(e.Schedule.Effects)
+		}
+	}
+}
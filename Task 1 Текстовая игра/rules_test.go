@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// дверь открывается только ключами и только после разговора с соседом
+const neighbourWorld = `{
+	"start": "квартира",
+	"items": [{"name": "рюкзак", "capacity": 5, "wear": "спина"}],
+	"rooms": [
+		{"name": "квартира", "description": "квартира", "items": ["ключи", "рюкзак"], "flags": ["свет"], "objects": [
+			{"name": "сосед", "actions": [
+				{"type": "use", "item": "ключи", "commentary": "сосед кивает",
+					"effects": [{"setFlag": "поговорили"}, {"counter": "разговоры", "add": 1}]}
+			]},
+			{"name": "дверь", "actions": [
+				{"type": "use", "item": "ключи", "commentary": "дверь открыта",
+					"if": {"all": [{"has": "ключи"}, {"flag": "поговорили"}, {"not": {"flag": "открыто"}}]},
+					"fail": "сначала поговори с соседом",
+					"effects": [{"setFlag": "открыто"}, {"describe": "квартира, дверь открыта"}]},
+				{"type": "go", "to": "подъезд", "if": {"flag": "открыто"}}
+			]},
+			{"name": "выключатель", "actions": [
+				{"type": "use", "item": "ключи", "commentary": "щелк",
+					"if": {"any": [{"counter": "разговоры", "atLeast": 2}, {"item": "ключи"}]},
+					"effects": [{"clearFlag": "свет"}, {"room": "подъезд", "setFlag": "темно"}]}
+			]}
+		]},
+		{"name": "подъезд", "description": "подъезд", "items": [], "objects": [
+			{"name": "квартира", "actions": [
+				{"type": "go", "to": "квартира", "if": {"flag": "темно"}, "fail": "страшно возвращаться"}
+			]}
+		]}
+	]
+}`
+
+func TestRules_ComposedConditions(t *testing.T) {
+	w, err := ParseWorld([]byte(neighbourWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	g.handleCommand("надеть рюкзак")
+
+	playSteps(t, g, []gameCase{
+		{1, "идти дверь", "путь закрыт"},
+		{2, "применить ключи дверь", "нет предмета в инвентаре - ключи"},
+		{3, "взять ключи", "предмет добавлен в инвентарь: ключи"},
+		{4, "применить ключи дверь", "сначала поговори с соседом"},
+		{5, "применить ключи выключатель", "ничего не произошло"},
+		{6, "применить ключи сосед", "сосед кивает"},
+		{7, "применить ключи сосед", "сосед кивает"},
+		{8, "применить ключи выключатель", "щелк"},
+		{9, "применить ключи дверь", "дверь открыта"},
+		{10, "осмотреться", "квартира, дверь открыта. можно пройти - дверь"},
+		{11, "идти дверь", "подъезд. можно пройти - квартира"},
+		{12, "осмотреться", "подъезд. можно пройти - квартира"},
+		{13, "идти квартира", "квартира, дверь открыта. можно пройти - дверь"},
+	})
+	if g.counters["разговоры"] != 2 {
+		t.Errorf("expected counter 2, got %d", g.counters["разговоры"])
+	}
+	if g.rooms["квартира"].Flags["свет"] {
+		t.Error("flag was not cleared")
+	}
+}
+
+func TestRules_AreData(t *testing.T) {
+	w, err := ParseWorld([]byte(neighbourWorld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseWorld(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(w, again) {
+		t.Error("world changed after JSON round trip")
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Snapshot - полное состояние игры: где игроки, что у них в инвентаре,
+// что лежит в комнатах, их описания, флаги и объекты, значения счетчиков
+type Snapshot struct {
+	Player   PlayerState    `json:"player"`
+	Players  []PlayerState  `json:"players,omitempty"`
+	Rooms    []RoomState    `json:"rooms"`
+	Counters map[string]int `json:"counters,omitempty"`
+	// Turn - сколько ходов прошло, Timers - еще не сработавшие таймеры
+	Turn   int     `json:"turn,omitempty"`
+	Timers []Timer `json:"timers,omitempty"`
+}
+
+type PlayerState struct {
+	Name     string              `json:"name,omitempty"`
+	Room     string              `json:"room"`
+	Items    []string            `json:"items"`
+	Contents map[string][]string `json:"contents,omitempty"`
+	// Goals - выполненные цели, Failed - проваленная
+	Goals  []string `json:"goals,omitempty"`
+	Failed string   `json:"failed,omitempty"`
+	// Dialogue - незаконченный разговор с персонажем
+	Dialogue *Dialogue `json:"dialogue,omitempty"`
+	// Visited - комнаты, в которых игрок побывал
+	Visited []string `json:"visited,omitempty"`
+}
+
+type RoomState struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Items       []string            `json:"items"`
+	Contents    map[string][]string `json:"contents,omitempty"`
+	// Places - где лежат предметы, выброшенные игроками оказываются на месте по умолчанию
+	Places  map[string]string `json:"places"`
+	Flags   []string          `json:"flags,omitempty"`
+	Objects []ObjectDef       `json:"objects"`
+	// Hidden - спрятанные предметы, которые еще не нашли
+	Hidden []string `json:"hidden,omitempty"`
+}
+
+var saveNameRe = regexp.MustCompile(`^[\p{L}\d_-]+$`)
+
+// Snapshot снимает текущее состояние игры
+func (g *Game) Snapshot() Snapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.snapshot()
+}
+
+// Restore возвращает игру в сохраненное состояние
+func (g *Game) Restore(s Snapshot) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.restore(s)
+}
+
+// Save сохраняет состояние игры в файл
+func (g *Game) Save(path string) error {
+	data, err := json.MarshalIndent(g.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Load восстанавливает состояние игры из файла
+func (g *Game) Load(path string) error {
+	s, err := readSnapshot(path)
+	if err != nil {
+		return err
+	}
+	return g.Restore(s)
+}
+
+func readSnapshot(path string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("не удалось разобрать сохранение: %w", err)
+	}
+	return s, nil
+}
+
+func (g *Game) snapshot() Snapshot {
+	s := Snapshot{Player: g.playerState(g.player), Counters: make(map[string]int), Turn: g.turn}
+	s.Timers = append(s.Timers, g.timers...)
+	for name, value := range g.counters {
+		s.Counters[name] = value
+	}
+	for _, name := range g.order {
+		s.Players = append(s.Players, g.playerState(g.players[name]))
+	}
+	for _, r := range g.world.Rooms {
+		room := g.rooms[r.Name]
+		rs := RoomState{
+			Name:        room.Name,
+			Description: room.Description,
+			Items:       append([]string{}, room.Items...),
+			Contents:    copyContents(room.Contents),
+			Places:      make(map[string]string, len(room.Places)),
+			Objects:     []ObjectDef{},
+			Hidden:      append([]string(nil), room.hidden...),
+		}
+		for item, place := range room.Places {
+			rs.Places[item] = place
+		}
+		for flag := range room.Flags {
+			rs.Flags = append(rs.Flags, flag)
+		}
+		sort.Strings(rs.Flags)
+		for _, name := range room.objectOrder {
+			if obj, ok := room.Objects[name]; ok {
+				rs.Objects = append(rs.Objects, obj.def)
+			}
+		}
+		s.Rooms = append(s.Rooms, rs)
+	}
+	return s
+}
+
+func (g *Game) playerState(p *Player) PlayerState {
+	ps := PlayerState{
+		Name:     p.Name,
+		Room:     p.room.Name,
+		Items:    append([]string{}, p.Items...),
+		Contents: copyContents(p.Contents),
+		Failed:   p.failed,
+	}
+	if p.dialogue != nil {
+		dialogue := *p.dialogue
+		ps.Dialogue = &dialogue
+	}
+	for _, goal := range g.world.Goals {
+		if p.goals[goal.Name] {
+			ps.Goals = append(ps.Goals, goal.Name)
+		}
+	}
+	for _, room := range g.world.Rooms {
+		if p.visited[room.Name] {
+			ps.Visited = append(ps.Visited, room.Name)
+		}
+	}
+	return ps
+}
+
+func copyContents(contents map[string][]string) map[string][]string {
+	if len(contents) == 0 {
+		return nil
+	}
+	result := make(map[string][]string, len(contents))
+	for container, items := range contents {
+		result[container] = append([]string{}, items...)
+	}
+	return result
+}
+
+// restore сначала проверяет, что сохранение подходит к миру игры,
+// и только потом меняет состояние, чтобы не оставить игру наполовину загруженной
+func (g *Game) restore(s Snapshot) error {
+	if err := g.checkSnapshot(s); err != nil {
+		return err
+	}
+
+	g.player = restorePlayer(s.Player, g.rooms)
+	g.players = make(map[string]*Player)
+	g.order = nil
+	for _, ps := range s.Players {
+		g.players[ps.Name] = restorePlayer(ps, g.rooms)
+		g.order = append(g.order, ps.Name)
+	}
+	g.counters = make(map[string]int)
+	for name, value := range s.Counters {
+		g.counters[name] = value
+	}
+	g.turn = s.Turn
+	g.timers = append([]Timer(nil), s.Timers...)
+	for _, rs := range s.Rooms {
+		room := g.rooms[rs.Name]
+		room.Description = rs.Description
+		room.Items = append([]string{}, rs.Items...)
+		room.Contents = copyContents(rs.Contents)
+		room.hidden = append([]string(nil), rs.Hidden...)
+		// в старых сохранениях мест нет - остаются места из описания мира
+		if rs.Places != nil {
+			room.Places = make(map[string]string, len(rs.Places))
+			for item, place := range rs.Places {
+				room.Places[item] = place
+			}
+		}
+		room.Flags = make(map[string]bool)
+		for _, flag := range rs.Flags {
+			room.Flags[flag] = true
+		}
+		room.Objects = make(map[string]GameObject)
+		room.objectOrder = nil
+		for _, def := range rs.Objects {
+			room.Objects[def.Name] = newObject(def)
+			room.objectOrder = append(room.objectOrder, def.Name)
+		}
+	}
+	return nil
+}
+
+// checkSnapshot проверяет сохранение по правилам мира: комнаты известны и
+// перечислены все, каждый предмет известен и лежит в одном месте, контейнеры
+// на месте, а объекты и таймеры ссылаются только на то, что есть в мире
+func (g *Game) checkSnapshot(s Snapshot) error {
+	var errs WorldErrors
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	rc := g.world.rules(addErr)
+	checkRoom := func(who, name string) {
+		if !rc.rooms[name] {
+			addErr("%s: неизвестная комната %q", who, name)
+		}
+	}
+	seen := make(map[string]bool)
+	checkItems := func(who string, items, hidden []string, contents map[string][]string) {
+		held := append(append([]string{}, items...), hidden...)
+		for _, inside := range contents {
+			held = append(held, inside...)
+		}
+		for _, item := range held {
+			rc.checkItem(who, item)
+			if seen[item] {
+				addErr("%s: предмет %q уже есть в другом месте", who, item)
+			}
+			seen[item] = true
+		}
+		for container := range contents {
+			if g.world.item(container).Capacity == 0 {
+				addErr("%s: %q не контейнер", who, container)
+			}
+		}
+		// каждый контейнер лежит сверху или в другом контейнере, но не в самом себе
+		for _, container := range unheld(append(append([]string{}, items...), hidden...), contents) {
+			addErr("%s: нет контейнера %q", who, container)
+		}
+	}
+
+	players := append([]PlayerState{s.Player}, s.Players...)
+	names := make(map[string]bool)
+	for i, p := range players {
+		who := "игрок"
+		if i > 0 {
+			who = fmt.Sprintf("игрок %q", p.Name)
+			if names[p.Name] {
+				addErr("%s сохранен несколько раз", who)
+			}
+			names[p.Name] = true
+		}
+		checkRoom(who, p.Room)
+		checkItems(who, p.Items, nil, p.Contents)
+	}
+	rooms := make(map[string]bool)
+	for _, r := range s.Rooms {
+		who := fmt.Sprintf("комната %q", r.Name)
+		checkRoom("комната", r.Name)
+		if rooms[r.Name] {
+			addErr("%s сохранена несколько раз", who)
+		}
+		rooms[r.Name] = true
+		checkItems(who, r.Items, r.Hidden, r.Contents)
+		objects := make(map[string]bool)
+		for _, obj := range r.Objects {
+			if objects[obj.Name] {
+				addErr("%s: объект %q сохранен несколько раз", who, obj.Name)
+			}
+			objects[obj.Name] = true
+			rc.checkObject(who, obj)
+		}
+	}
+	for _, r := range g.world.Rooms {
+		if !rooms[r.Name] {
+			addErr("нет комнаты %q", r.Name)
+		}
+	}
+	for i, t := range s.Timers {
+		rc.checkEffects(fmt.Sprintf("таймер %d", i+1), t.Effects)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func restorePlayer(ps PlayerState, rooms map[string]*Room) *Player {
+	p := &Player{
+		Name:      ps.Name,
+		Inventory: Inventory{Items: append([]string{}, ps.Items...), Contents: copyContents(ps.Contents)},
+		room:      rooms[ps.Room],
+		failed:    ps.Failed,
+	}
+	if ps.Dialogue != nil {
+		dialogue := *ps.Dialogue
+		p.dialogue = &dialogue
+	}
+	if len(ps.Goals) > 0 {
+		p.goals = make(map[string]bool, len(ps.Goals))
+		for _, goal := range ps.Goals {
+			p.goals[goal] = true
+		}
+	}
+	// в старых сохранениях карты нет - игрок знает хотя бы комнату, где стоит
+	for _, name := range ps.Visited {
+		if room, ok := rooms[name]; ok {
+			p.visit(room)
+		}
+	}
+	p.visit(p.room)
+	return p
+}
+
+// savePath проверяет имя сохранения из команды игрока и строит путь к файлу
+func (g *Game) savePath(player *Player, name string) (string, string) {
+	if g.SaveDir == "" {
+		return "", g.tr(player, "сохранение недоступно")
+	}
+	if player != g.player {
+		return "", g.tr(player, "в общей игре сохранение недоступно")
+	}
+	if !saveNameRe.MatchString(name) {
+		return "", g.tr(player, "некорректное имя сохранения")
+	}
+	return filepath.Join(g.SaveDir, name+".json"), ""
+}
+
+func (g *Game) saveCommand(player *Player, name string) (bool, string) {
+	path, problem := g.savePath(player, name)
+	if problem != "" {
+		return false, problem
+	}
+	data, err := json.MarshalIndent(g.snapshot(), "", "  ")
+	if err == nil {
+		err = os.MkdirAll(g.SaveDir, 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		return false, g.tr(player, "не удалось сохранить игру")
+	}
+	return true, g.tr(player, "игра сохранена: %s", name)
+}
+
+func (g *Game) loadCommand(player *Player, name string) (bool, string) {
+	path, problem := g.savePath(player, name)
+	if problem != "" {
+		return false, problem
+	}
+	s, err := readSnapshot(path)
+	if os.IsNotExist(err) {
+		return false, g.tr(player, "нет такого сохранения")
+	}
+	if err != nil || g.restore(s) != nil {
+		return false, g.tr(player, "не удалось загрузить игру")
+	}
+	return true, g.tr(player, "игра загружена: %s", name)
+}
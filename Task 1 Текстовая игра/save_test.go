@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func playSteps(t *testing.T, g *Game, steps []gameCase) {
+	t.Helper()
+	for _, step := range steps {
+		if answer := g.handleCommand(step.command); answer != step.answer {
+			t.Fatalf("step %d, %q:\n\texpected %q\n\tgot      %q", step.step, step.command, step.answer, answer)
+		}
+	}
+}
+
+func TestSave_RestoresExactState(t *testing.T) {
+	w := testWorld(t)
+	g := NewGame(w)
+	// ключи, конспекты и открытая дверь
+	playSteps(t, g, gameCases[0][:9])
+
+	path := filepath.Join(t.TempDir(), "game.json")
+	if err := g.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewGame(w)
+	if err := restored.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g.Snapshot(), restored.Snapshot()) {
+		t.Fatalf("snapshots differ:\n%+v\n%+v", g.Snapshot(), restored.Snapshot())
+	}
+	// дверь осталась открытой, описание комнаты - актуальным
+	playSteps(t, restored, []gameCase{
+		{1, "идти улица", "на улице весна. можно пройти - домой"},
+		{2, "идти домой", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{3, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{4, "осмотреться", "пустая комната. можно пройти - коридор"},
+	})
+}
+
+func TestSave_Commands(t *testing.T) {
+	g := NewGame(testWorld(t))
+	g.SaveDir = filepath.Join(t.TempDir(), "saves")
+
+	playSteps(t, g, []gameCase{
+		{1, "загрузить", "нет такого сохранения"},
+		{2, "идти коридор", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{3, "сохранить утро", "игра сохранена: утро"},
+		{4, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{5, "надеть рюкзак", "вы надели: рюкзак"},
+		{6, "сохранить", "игра сохранена: autosave"},
+		{7, "загрузить утро", "игра загружена: утро"},
+		{8, "осмотреться", "ничего интересного. можно пройти - кухня, комната, улица"},
+		{9, "идти комната", "ты в своей комнате. можно пройти - коридор"},
+		{10, "осмотреться", "на столе: ключи, конспекты, на стуле: рюкзак. можно пройти - коридор"},
+		{11, "загрузить", "игра загружена: autosave"},
+		{12, "осмотреться", "на столе: ключи, конспекты. можно пройти - коридор"},
+		{13, "сохранить ../../etc/passwd", "некорректное имя сохранения"},
+	})
+
+	shared := newSharedGame(t, "Kate")
+	shared.SaveDir = g.SaveDir
+	msgs := shared.HandleCommand("Kate", "сохранить")
+	if len(msgs) != 1 || msgs[0].Text != "в общей игре сохранение недоступно" {
+		t.Errorf("unexpected answer in shared game: %v", msgs)
+	}
+
+	noDir := NewGame(testWorld(t))
+	if got := noDir.handleCommand("сохранить"); got != "сохранение недоступно" {
+		t.Errorf("unexpected answer without save dir: %q", got)
+	}
+}
+
+func TestSave_LoadTurnGoesOnForLoadedPlayer(t *testing.T) {
+	w, err := ParseWorld([]byte(`{
+		"start": "дом",
+		"events": [{"every": 1, "if": {"at": "двор"}, "effects": [{"give": "монета"}]}],
+		"goals": [{"name": "клад", "points": 1, "done": {"has": "монета"}}],
+		"rooms": [
+			{"name": "дом", "items": [], "objects": [{"name": "двор", "actions": [{"type": "go", "to": "двор"}]}]},
+			{"name": "двор", "items": [], "objects": []}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGame(w)
+	g.SaveDir = t.TempDir()
+	s := g.Snapshot()
+	s.Player.Room = "двор"
+	data, _ := json.Marshal(s)
+	if err := os.WriteFile(filepath.Join(g.SaveDir, "двор.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// событие хода загрузки срабатывает уже для загруженного игрока
+	playSteps(t, g, []gameCase{
+		{1, "загрузить двор", "игра загружена: двор"},
+		{2, "инвентарь", "в руках: монета"},
+	})
+	if summary, over := g.Result(); !over || !strings.HasPrefix(summary, "победа") {
+		t.Errorf("goal of the loaded player must be done: %q", summary)
+	}
+}
+
+func TestSave_RestoreRejectsForeignSnapshot(t *testing.T) {
+	g := NewGame(testWorld(t))
+	before := g.Snapshot()
+	for _, tc := range []struct {
+		name  string
+		spoil func(s *Snapshot)
+		want  string
+	}{
+		{"room", func(s *Snapshot) { s.Player.Room = "чердак" }, `игрок: неизвестная комната "чердак"`},
+		{"exit", func(s *Snapshot) {
+			s.Rooms[0].Objects = append(s.Rooms[0].Objects, ObjectDef{Name: "люк", Actions: []ActionDef{{Type: "go", To: "нигде"}}})
+		}, `комната "кухня", объект "люк": переход в неизвестную комнату "нигде"`},
+		{"item", func(s *Snapshot) { s.Player.Items = append(s.Player.Items, "меч") }, `игрок: неизвестный предмет "меч"`},
+		{"twice", func(s *Snapshot) { s.Player.Items = append(s.Player.Items, "чай") }, `предмет "чай" уже есть в другом месте`},
+		{"container", func(s *Snapshot) { s.Player.Contents = map[string][]string{"рюкзак": {}} }, `игрок: нет контейнера "рюкзак"`},
+		{"self", func(s *Snapshot) {
+			s.Rooms[2].Items = []string{"ключи", "конспекты"}
+			s.Player.Contents = map[string][]string{"рюкзак": {"рюкзак"}}
+		}, `игрок: нет контейнера "рюкзак"`},
+		{"room self", func(s *Snapshot) {
+			s.Rooms[2].Items = []string{"ключи", "конспекты"}
+			s.Rooms[2].Contents = map[string][]string{"рюкзак": {"рюкзак"}}
+		}, `комната "комната": нет контейнера "рюкзак"`},
+		{"cycle", func(s *Snapshot) {
+			s.Rooms[2].Items = []string{"ключи"}
+			s.Player.Contents = map[string][]string{"рюкзак": {"конспекты"}, "конспекты": {"рюкзак"}}
+		}, `игрок: нет контейнера "конспекты"`},
+		{"timer", func(s *Snapshot) { s.Timers = []Timer{{At: 3, Effects: []Effect{{Room: "подвал"}}}} }, `таймер 1, эффект 1: неизвестная комната "подвал"`},
+		{"missing", func(s *Snapshot) { s.Rooms = s.Rooms[1:] }, `нет комнаты "кухня"`},
+	} {
+		bad := g.Snapshot()
+		tc.spoil(&bad)
+		if err := g.Restore(bad); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error %q, got %v", tc.name, tc.want, err)
+		}
+		if !reflect.DeepEqual(before, g.Snapshot()) {
+			t.Errorf("%s: failed restore changed the game", tc.name)
+		}
+	}
+	if got := g.handleCommand("идти коридор"); got != "ничего интересного. можно пройти - кухня, комната, улица" {
+		t.Errorf("game must stay playable, got %q", got)
+	}
+}
+
+func TestAPI_Snapshot(t *testing.T) {
+	api := NewAPI(testWorld(t))
+	_, created := doJSON(t, api, "POST", "/sessions", "")
+	doJSON(t, api, "POST", "/sessions/"+created.ID+"/commands", `{"command": "идти коридор"}`)
+
+	req := httptest.NewRequest("GET", "/sessions/"+created.ID+"/snapshot", nil)
+	rr := httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("snapshot: code %d", rr.Code)
+	}
+	saved := rr.Body.String()
+
+	_, other := doJSON(t, api, "POST", "/sessions", "")
+	code, resp := doJSON(t, api, "PUT", "/sessions/"+other.ID+"/snapshot", saved)
+	if code != http.StatusOK || resp.State.Room != "коридор" {
+		t.Fatalf("restore: code=%d resp=%+v", code, resp)
+	}
+
+	var s Snapshot
+	json.Unmarshal([]byte(saved), &s)
+	s.Player.Room = "чердак"
+	data, _ := json.Marshal(s)
+	req = httptest.NewRequest("PUT", "/sessions/"+other.ID+"/snapshot", strings.NewReader(string(data)))
+	rr = httptest.NewRecorder()
+	api.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for foreign snapshot, got %d", rr.Code)
+	}
+}
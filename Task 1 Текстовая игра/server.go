@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	greeting      = "Добро пожаловать в квест!"
+	goodbye       = "Спасибо за игру!"
+	askName       = "Как вас зовут?"
+	serverFull    = "сервер переполнен, попробуйте позже"
+	idleTimeout   = "время ожидания истекло"
+	outboxSize    = 64
+	writeDeadline = 5 * time.Second
+)
+
+// ErrServerClosed возвращается из Serve после вызова Close
+var ErrServerClosed = errors.New("game server closed")
+
+// Server - TCP-фронтенд игры. Каждая строка от клиента - одна команда,
+// каждый ответ игры отправляется отдельной строкой.
+type Server struct {
+	World *World
+	// Shared - все подключения играют в одном мире под своими именами,
+	// иначе у каждого подключения своя отдельная игра
+	Shared bool
+	// MaxConns - сколько подключений обслуживается одновременно, 0 - без ограничений
+	MaxConns int
+	// IdleTimeout - через сколько молчания клиента соединение закрывается, 0 - никогда
+	IdleTimeout time.Duration
+	// Locale - язык новых игроков, пустой - язык по умолчанию. Сменить язык
+	// игрок может сам командой "язык"
+	Locale string
+
+	mu       sync.Mutex
+	game     *Game
+	listener net.Listener
+	sessions map[*session]struct{}
+	players  map[string]*session
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// session - одно подключение к серверу
+type session struct {
+	conn net.Conn
+	out  chan string
+	done chan struct{}
+	once sync.Once
+}
+
+func (s *session) send(line string) {
+	select {
+	case s.out <- line:
+	case <-s.done:
+	default:
+		// клиент не успевает читать - не держим из-за него остальных
+		s.close()
+	}
+}
+
+func (s *session) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.conn.Close()
+	})
+}
+
+func (s *session) write(line string) bool {
+	s.conn.SetWriteDeadline(time.Now().Add(writeDeadline))
+	if _, err := s.conn.Write([]byte(line + "\n")); err != nil {
+		s.close()
+		return false
+	}
+	return true
+}
+
+// writeLoop отправляет клиенту накопленные сообщения. После quit
+// досылает то, что уже успело попасть в очередь, и завершается
+func (s *session) writeLoop(quit <-chan struct{}) {
+	for {
+		select {
+		case line := <-s.out:
+			if !s.write(line) {
+				return
+			}
+		case <-quit:
+			for {
+				select {
+				case line := <-s.out:
+					if !s.write(line) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Serve принимает подключения, пока listener не закроется
+func (srv *Server) Serve(l net.Listener) error {
+	srv.mu.Lock()
+	if srv.closed {
+		srv.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	srv.listener = l
+	srv.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			srv.mu.Lock()
+			closed := srv.closed
+			srv.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		srv.wg.Add(1)
+		go func() {
+			defer srv.wg.Done()
+			srv.ServeConn(conn)
+		}()
+	}
+}
+
+// ServeConn обслуживает одно подключение до его закрытия
+func (srv *Server) ServeConn(conn net.Conn) {
+	s := &session{conn: conn, out: make(chan string, outboxSize), done: make(chan struct{})}
+	if !srv.register(s) {
+		conn.SetWriteDeadline(time.Now().Add(writeDeadline))
+		conn.Write([]byte(translate(srv.Locale, serverFull) + "\n"))
+		conn.Close()
+		return
+	}
+	defer srv.unregister(s)
+	defer s.close()
+
+	quit := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		s.writeLoop(quit)
+		close(writerDone)
+	}()
+
+	srv.play(s)
+
+	// даем писателю дослать последние ответы, прежде чем закрыть соединение
+	close(quit)
+	<-writerDone
+}
+
+func (srv *Server) play(s *session) {
+	// tr - перевод на язык игрока; пока игры нет - на язык сервера
+	tr := func(text string) string { return translate(srv.Locale, text) }
+	lines := bufio.NewScanner(s.conn)
+	readLine := func() (string, bool) {
+		if srv.IdleTimeout > 0 {
+			s.conn.SetReadDeadline(time.Now().Add(srv.IdleTimeout))
+		}
+		if !lines.Scan() {
+			var netErr net.Error
+			if errors.As(lines.Err(), &netErr) && netErr.Timeout() {
+				s.send(tr(idleTimeout))
+			}
+			return "", false
+		}
+		return strings.TrimSpace(lines.Text()), true
+	}
+
+	s.send(tr(greeting))
+
+	// отдельная игра для подключения
+	if !srv.Shared {
+		g := NewGame(srv.World)
+		if srv.Locale != "" {
+			g.SetLocale(srv.Locale)
+		}
+		tr = func(text string) string { return g.Translate("", text) }
+		for {
+			input, ok := readLine()
+			if !ok {
+				return
+			}
+			if input == "" {
+				continue
+			}
+			reply := g.handleCommand(input)
+			if reply != "" {
+				s.send(reply)
+			}
+			if reply == g.Translate("", goodbye) {
+				return
+			}
+			if summary, over := g.Result(); over {
+				s.send(summary)
+				return
+			}
+		}
+	}
+
+	// общий мир: сначала знакомимся
+	var name string
+	for {
+		s.send(tr(askName))
+		input, ok := readLine()
+		if !ok {
+			return
+		}
+		if err := srv.join(input, s); err != nil {
+			s.send(err.Error())
+			continue
+		}
+		name = input
+		if srv.Locale != "" {
+			srv.game.SetPlayerLocale(name, srv.Locale)
+		}
+		tr = func(text string) string { return srv.game.Translate(name, text) }
+		break
+	}
+	defer srv.leave(name)
+
+	for {
+		input, ok := readLine()
+		if !ok {
+			return
+		}
+		if input == "" {
+			continue
+		}
+		finished := false
+		for _, msg := range srv.game.HandleCommand(name, input) {
+			srv.deliver(msg)
+			if msg.To == name && msg.Text == srv.game.Translate(name, goodbye) {
+				finished = true
+			}
+		}
+		if summary, over := srv.game.PlayerResult(name); over && !finished {
+			s.send(summary)
+			finished = true
+		}
+		if finished {
+			return
+		}
+	}
+}
+
+func (srv *Server) register(s *session) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.closed || (srv.MaxConns > 0 && len(srv.sessions) >= srv.MaxConns) {
+		return false
+	}
+	if srv.sessions == nil {
+		srv.sessions = make(map[*session]struct{})
+		srv.players = make(map[string]*session)
+	}
+	if srv.Shared && srv.game == nil {
+		srv.game = NewGame(srv.World)
+		if srv.Locale != "" {
+			srv.game.SetLocale(srv.Locale)
+		}
+	}
+	srv.sessions[s] = struct{}{}
+	return true
+}
+
+func (srv *Server) unregister(s *session) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	delete(srv.sessions, s)
+}
+
+func (srv *Server) join(name string, s *session) error {
+	if _, err := srv.game.AddPlayer(name); err != nil {
+		return err
+	}
+	srv.mu.Lock()
+	srv.players[name] = s
+	srv.mu.Unlock()
+	return nil
+}
+
+func (srv *Server) leave(name string) {
+	srv.game.RemovePlayer(name)
+	srv.mu.Lock()
+	delete(srv.players, name)
+	srv.mu.Unlock()
+}
+
+// deliver отправляет сообщение подключению игрока, которому оно адресовано
+func (srv *Server) deliver(msg Message) {
+	srv.mu.Lock()
+	s, ok := srv.players[msg.To]
+	srv.mu.Unlock()
+	if ok {
+		s.send(msg.Text)
+	}
+}
+
+// Close перестает принимать подключения, закрывает текущие и ждет их завершения
+func (srv *Server) Close() error {
+	srv.mu.Lock()
+	srv.closed = true
+	var err error
+	if srv.listener != nil {
+		err = srv.listener.Close()
+	}
+	for s := range srv.sessions {
+		s.close()
+	}
+	srv.mu.Unlock()
+	srv.wg.Wait()
+	return err
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newTestClient(t *testing.T, conn net.Conn) *testClient {
+	return &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *testClient) send(line string) {
+	c.t.Helper()
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	if _, err := c.conn.Write([]byte(line + "\n")); err != nil {
+		c.t.Fatalf("write %q: %v", line, err)
+	}
+}
+
+func (c *testClient) expect(want string) {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("waiting for %q: %v", want, err)
+	}
+	if got := strings.TrimRight(line, "\n"); got != want {
+		c.t.Fatalf("expected %q\n\tgot %q", want, got)
+	}
+}
+
+func (c *testClient) expectClosed() {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	if line, err := c.r.ReadString('\n'); err == nil {
+		c.t.Fatalf("expected closed connection, got %q", line)
+	}
+}
+
+func testWorld(t *testing.T) *World {
+	t.Helper()
+	w, err := ParseWorld(defaultWorld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func startServer(t *testing.T, srv *Server) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return newTestClient(t, conn)
+}
+
+func TestServer_PipeSession(t *testing.T) {
+	server, client := net.Pipe()
+	srv := &Server{World: testWorld(t)}
+	done := make(chan struct{})
+	go func() {
+		srv.ServeConn(server)
+		close(done)
+	}()
+
+	c := newTestClient(t, client)
+	c.expect(greeting)
+	for _, step := range gameCases[0] {
+		c.send(step.command)
+		c.expect(step.answer)
+	}
+	client.Close()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("session did not finish after disconnect")
+	}
+}
+
+func TestServer_SeparateGamesPerConnection(t *testing.T) {
+	addr := startServer(t, &Server{World: testWorld(t)})
+
+	first, second := dial(t, addr), dial(t, addr)
+	first.expect(greeting)
+	second.expect(greeting)
+
+	first.send("идти коридор")
+	first.expect("ничего интересного. можно пройти - кухня, комната, улица")
+	second.send("осмотреться")
+	second.expect(gameCases[0][0].answer)
+}
+
+func TestServer_ExitClosesConnection(t *testing.T) {
+	addr := startServer(t, &Server{World: testWorld(t)})
+
+	c := dial(t, addr)
+	c.expect(greeting)
+	c.send("выйти из игры")
+	c.expect(goodbye)
+	c.expectClosed()
+}
+
+func TestServer_SharedWorld(t *testing.T) {
+	addr := startServer(t, &Server{World: testWorld(t), Shared: true})
+
+	kate := dial(t, addr)
+	kate.expect(greeting)
+	kate.expect(askName)
+	kate.send("Kate")
+
+	tristan := dial(t, addr)
+	tristan.expect(greeting)
+	tristan.expect(askName)
+	tristan.send("Kate")
+	tristan.expect("игрок Kate уже в игре")
+	tristan.expect(askName)
+	tristan.send("Tristan")
+
+	tristan.send("осмотреться")
+	tristan.expect(gameCases[0][0].answer + ". Кроме вас тут ещё Kate")
+
+	kate.send("сказать_игроку Tristan привет")
+	kate.expect("вы шепнули Tristan: привет")
+	tristan.expect("Kate говорит вам: привет")
+
+	tristan.send("сказать пока")
+	tristan.expect("Tristan говорит: пока")
+	kate.expect("Tristan говорит: пока")
+
+	tristan.conn.Close()
+	// после отключения игрок пропадает из мира
+	deadline := time.Now().Add(time.Second)
+	for {
+		kate.send("осмотреться")
+		kate.conn.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := kate.r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimRight(line, "\n") == gameCases[0][0].answer {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("disconnected player is still in the room: %q", line)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_MaxConns(t *testing.T) {
+	addr := startServer(t, &Server{World: testWorld(t), MaxConns: 1})
+
+	first := dial(t, addr)
+	first.expect(greeting)
+
+	second := dial(t, addr)
+	second.expect(serverFull)
+	second.expectClosed()
+
+	first.conn.Close()
+	// место освобождается, когда первое подключение обработано
+	deadline := time.Now().Add(time.Second)
+	for {
+		third := dial(t, addr)
+		third.conn.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := third.r.ReadString('\n')
+		if err == nil && strings.TrimRight(line, "\n") == greeting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("slot was not released: %q, %v", line, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_IdleTimeout(t *testing.T) {
+	addr := startServer(t, &Server{World: testWorld(t), IdleTimeout: 50 * time.Millisecond})
+
+	c := dial(t, addr)
+	c.expect(greeting)
+	c.expect(idleTimeout)
+	c.expectClosed()
+}
+
+func TestServer_Close(t *testing.T) {
+	srv := &Server{World: testWorld(t)}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(l) }()
+
+	c := dial(t, l.Addr().String())
+	c.expect(greeting)
+
+	srv.Close()
+	c.expectClosed()
+	select {
+	case err := <-served:
+		if err != ErrServerClosed {
+			t.Errorf("expected ErrServerClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Serve did not return after Close")
+	}
+}
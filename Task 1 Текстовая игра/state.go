@@ -0,0 +1,87 @@
+package main
+
+// State - состояние игрока в удобном для клиентов виде
+type State struct {
+	Room      string   `json:"room"`
+	Inventory []string `json:"inventory"`
+	Exits     []string `json:"exits"`
+	Score     int      `json:"score"`
+	// Finished - игра окончена, Summary - ее итог
+	Finished bool   `json:"finished,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+}
+
+// State возвращает состояние игрока одиночной игры
+func (g *Game) State() State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stateOf(g.player)
+}
+
+// Play выполняет команду одиночной игры и возвращает ответ вместе с состоянием после нее
+func (g *Game) Play(command string) (string, State) {
+	var reply string
+	var state State
+	g.turnLocked(func() {
+		reply = g.resolveReaction(command, g.player)
+		state = g.stateOf(g.player)
+	})
+	return reply, state
+}
+
+// Actions - команды, которые сейчас стоит предложить игроку одиночной игры,
+// на его языке: осмотреться и инвентарь, затем то же, что пробует проверка мира.
+// Во время разговора - только варианты ответа, после конца игры - ничего
+func (g *Game) Actions() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	player := g.player
+	if _, over := g.outcome(player); over {
+		return nil
+	}
+	loc := g.localeOf(player)
+	var actions []string
+	if player.dialogue == nil {
+		actions = append(actions, loc.format(Command{Verb: "look"}), loc.format(Command{Verb: "inventory"}))
+	}
+	for _, m := range g.moves(player) {
+		cmd := m.cmd
+		cmd.Object, cmd.Target = g.local(player, cmd.Object), g.local(player, cmd.Target)
+		actions = append(actions, loc.format(cmd))
+	}
+	return actions
+}
+
+func (g *Game) stateOf(player *Player) State {
+	score, _ := g.score(player)
+	summary, finished := g.outcome(player)
+	return State{
+		Room:      g.local(player, player.room.Name),
+		Inventory: g.localList(player, player.allItems()),
+		Exits:     g.localList(player, player.room.exits()),
+		Score:     score,
+		Finished:  finished,
+		Summary:   summary,
+	}
+}
+
+// exits - выходы из комнаты: объекты, через которые можно куда-то пойти
+func (room *Room) exits() []string {
+	exits := []string{}
+	for _, name := range room.objectOrder {
+		obj, ok := room.Objects[name]
+		if !ok {
+			continue
+		}
+		for _, action := range obj.Actions {
+			if action.action == ActionGo {
+				if obj.Exit != "" {
+					name = obj.Exit
+				}
+				exits = append(exits, name)
+				break
+			}
+		}
+	}
+	return exits
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// World - описание игрового мира в том виде, в каком оно лежит в файле.
+// По нему NewGame собирает комнаты, объекты и действия каждой игры.
+type World struct {
+	Start string    `json:"start"`
+	Rooms []RoomDef `json:"rooms"`
+	// Items - каталог свойств предметов: вес, вместимость, слот
+	Items []ItemDef `json:"items,omitempty"`
+	// Goals - цели квеста, за которые начисляются очки
+	Goals []Goal `json:"goals,omitempty"`
+	// NPCs - персонажи и их диалоги
+	NPCs []NPCDef `json:"npcs,omitempty"`
+	// Recipes - какие предметы можно соединить и что из них получится
+	Recipes []Recipe `json:"recipes,omitempty"`
+	// Clock - игровые часы, Events - события по расписанию
+	Clock  *ClockDef  `json:"clock,omitempty"`
+	Events []EventDef `json:"events,omitempty"`
+	// Translations - переводы текстов мира по языкам: имен комнат, предметов,
+	// объектов и персонажей, описаний и реплик ({"en": {"ключи": "keys"}})
+	Translations map[string]map[string]string `json:"translations,omitempty"`
+}
+
+// RoomDef - комната. Ее описание собирается из частей: Description,
+// предметов по местам (Places), первой подходящей приписки из Notes и выходов.
+// Если предметов нет, вместо них выводится Empty
+type RoomDef struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Enter - что игрок видит, войдя в комнату, по умолчанию полное описание
+	Enter string   `json:"enter,omitempty"`
+	Empty string   `json:"empty,omitempty"`
+	Items []string `json:"items"`
+	// Contents - что лежит в контейнерах комнаты: {"шкаф": ["шапка"]}
+	Contents map[string][]string `json:"contents,omitempty"`
+	// Places - где лежат предметы: {"ключи": "на столе"}
+	Places map[string]string `json:"places,omitempty"`
+	// Hidden - спрятанные предметы: их не видно и не взять, пока их не покажет эффект reveal
+	Hidden []string `json:"hidden,omitempty"`
+	// Place - куда попадают предметы без своего места, по умолчанию "на полу"
+	Place   string      `json:"place,omitempty"`
+	Notes   []Note      `json:"notes,omitempty"`
+	Flags   []string    `json:"flags,omitempty"`
+	Objects []ObjectDef `json:"objects"`
+}
+
+// Note - приписка к описанию комнаты, которая выводится, если выполнено условие
+type Note struct {
+	If   *Condition `json:"if,omitempty"`
+	Text string     `json:"text"`
+}
+
+type ObjectDef struct {
+	Name string `json:"name"`
+	// Exit - под каким именем объект служит выходом из комнаты (дверь ведет на "улица")
+	Exit string `json:"exit,omitempty"`
+	// State - состояние объекта: locked, closed, open или broken. Через объект
+	// с состоянием можно пройти, только пока он открыт
+	State   string      `json:"state,omitempty"`
+	Actions []ActionDef `json:"actions"`
+}
+
+type ActionDef struct {
+	Type string `json:"type"`
+	// Item - какой предмет применяется в действии use. Для open, close, lock
+	// и break - инструмент, который должен быть у игрока
+	Item string `json:"item,omitempty"`
+	// From - в каких состояниях объекта действие доступно, State - состояние после него
+	From  []string `json:"from,omitempty"`
+	State string   `json:"state,omitempty"`
+	// To - комната, в которую ведет действие go
+	To string `json:"to,omitempty"`
+	// If - условие, без которого действие не срабатывает, тогда игрок получает Fail
+	If      *Condition `json:"if,omitempty"`
+	Effects []Effect   `json:"effects,omitempty"`
+
+	Commentary string `json:"commentary,omitempty"`
+	Fail       string `json:"fail,omitempty"`
+}
+
+var actionTypes = map[string]ActionType{
+	"take":  ActionTake,
+	"look":  ActionLook,
+	"go":    ActionGo,
+	"use":   ActionUse,
+	"open":  ActionOpen,
+	"close": ActionClose,
+	"lock":  ActionLock,
+	"break": ActionBreak,
+}
+
+// WorldErrors - все ошибки, найденные при проверке мира
+type WorldErrors []error
+
+func (e WorldErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// LoadWorld читает мир из JSON-файла и проверяет его
+func LoadWorld(path string) (*World, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWorld(data)
+}
+
+// ParseWorld разбирает мир из JSON и проверяет ссылки между комнатами и предметами
+func ParseWorld(data []byte) (*World, error) {
+	w := &World{}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать мир: %w", err)
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Validate собирает сразу все ошибки в описании мира, а не останавливается на первой
+func (w *World) Validate() error {
+	var errs WorldErrors
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	roomNames := make(map[string]bool)
+	items := make(map[string]bool)
+	rc := &ruleChecker{
+		rooms:    roomNames,
+		items:    items,
+		flags:    make(map[string]bool),
+		counters: make(map[string]bool),
+		periods:  make(map[string]bool),
+		addErr:   addErr,
+	}
+	w.validateClock(rc, addErr)
+	for _, e := range w.Events {
+		rc.collectEffects(e.Effects)
+	}
+	for _, npc := range w.NPCs {
+		for _, node := range npc.Dialogue {
+			rc.collectEffects(node.Effects)
+			for _, choice := range node.Choices {
+				rc.collectEffects(choice.Effects)
+			}
+		}
+	}
+	for _, r := range w.Recipes {
+		if r.Result != "" {
+			items[r.Result] = true
+		}
+	}
+	for _, r := range w.Rooms {
+		for _, flag := range r.Flags {
+			rc.flags[flag] = true
+		}
+		for _, obj := range r.Objects {
+			rc.collectRules(obj)
+		}
+		if r.Name == "" {
+			addErr("комната без имени")
+			continue
+		}
+		if roomNames[r.Name] {
+			addErr("комната %q описана несколько раз", r.Name)
+		}
+		roomNames[r.Name] = true
+		roomItems := append(append([]string{}, r.Items...), r.Hidden...)
+		for _, contents := range r.Contents {
+			roomItems = append(roomItems, contents...)
+		}
+		for _, item := range roomItems {
+			if items[item] {
+				addErr("комната %q: предмет %q уже есть в мире", r.Name, item)
+			}
+			items[item] = true
+		}
+	}
+
+	catalog := make(map[string]bool)
+	for _, def := range w.Items {
+		if def.Name == "" {
+			addErr("предмет без имени в каталоге")
+			continue
+		}
+		if catalog[def.Name] {
+			addErr("предмет %q описан в каталоге несколько раз", def.Name)
+		}
+		catalog[def.Name] = true
+		if def.Weight < 0 || def.Capacity < 0 {
+			addErr("предмет %q: вес и вместимость не могут быть отрицательными", def.Name)
+		}
+		if !items[def.Name] {
+			addErr("предмет %q из каталога нигде не встречается", def.Name)
+		}
+	}
+
+	if w.Start == "" {
+		addErr("не указана стартовая комната")
+	} else if !roomNames[w.Start] {
+		addErr("стартовая комната %q не существует", w.Start)
+	}
+
+	for _, r := range w.Rooms {
+		if r.Name == "" {
+			continue
+		}
+		where := fmt.Sprintf("комната %q", r.Name)
+		inRoom := make(map[string]bool)
+		for _, item := range append(append([]string{}, r.Items...), r.Hidden...) {
+			inRoom[item] = true
+		}
+		for item := range r.Places {
+			if !inRoom[item] {
+				addErr("%s: место указано для предмета %q, которого нет в комнате", where, item)
+			}
+		}
+		containers := make([]string, 0, len(r.Contents))
+		for container := range r.Contents {
+			containers = append(containers, container)
+		}
+		sort.Strings(containers)
+		for _, container := range containers {
+			contents := r.Contents[container]
+			def := w.item(container)
+			if def.Capacity == 0 {
+				addErr("%s: %q не контейнер, в нем ничего не может лежать", where, container)
+				continue
+			}
+			load := 0
+			for _, item := range contents {
+				load += w.item(item).Weight
+			}
+			if load > def.Capacity {
+				addErr("%s: в %q не помещается столько предметов", where, container)
+			}
+		}
+		for _, container := range unheld(append(append([]string{}, r.Items...), r.Hidden...), r.Contents) {
+			addErr("%s: контейнера %q нет в комнате", where, container)
+		}
+		for _, note := range r.Notes {
+			rc.checkCondition(where, note.If)
+		}
+		objects := make(map[string]bool)
+		for _, obj := range r.Objects {
+			for _, name := range []string{obj.Name, obj.Exit} {
+				if name == "" {
+					continue
+				}
+				if objects[name] {
+					addErr("%s: имя %q занято другим объектом", where, name)
+				}
+				objects[name] = true
+			}
+			rc.checkObject(where, obj)
+		}
+	}
+
+	w.validateNPCs(rc, addErr)
+	w.validateRecipes(rc, addErr)
+	w.validateTranslations(addErr)
+
+	for i, e := range w.Events {
+		where := fmt.Sprintf("событие %d", i+1)
+		if e.At < 0 || e.Every < 0 || (e.At == 0 && e.Every == 0) {
+			addErr("%s: не указано, на каком ходу оно срабатывает", where)
+		}
+		rc.checkCondition(where, e.If)
+		rc.checkEffects(where, e.Effects)
+	}
+
+	goals := make(map[string]bool)
+	for _, goal := range w.Goals {
+		if goal.Name == "" {
+			addErr("цель без имени")
+			continue
+		}
+		where := fmt.Sprintf("цель %q", goal.Name)
+		if goals[goal.Name] {
+			addErr("%s описана несколько раз", where)
+		}
+		goals[goal.Name] = true
+		if goal.Done == nil {
+			addErr("%s: не указано, когда она выполнена", where)
+		}
+		if goal.Points < 0 {
+			addErr("%s: очки не могут быть отрицательными", where)
+		}
+		rc.checkCondition(where, goal.Done)
+		rc.checkCondition(where, goal.Fail)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// build создает комнаты по описанию мира и возвращает их вместе со стартовой
+func (w *World) build() (map[string]*Room, *Room) {
+	built := make(map[string]*Room, len(w.Rooms))
+	for _, r := range w.Rooms {
+		room := &Room{
+			Inventory:   Inventory{Items: append([]string{}, r.Items...)},
+			Name:        r.Name,
+			Description: r.Description,
+			Objects:     make(map[string]GameObject),
+			Flags:       make(map[string]bool),
+			Places:      make(map[string]string),
+			enter:       r.Enter,
+			empty:       r.Empty,
+			place:       r.Place,
+			notes:       r.Notes,
+			hidden:      append([]string(nil), r.Hidden...),
+		}
+		for item, place := range r.Places {
+			room.Places[item] = place
+		}
+		if len(r.Contents) > 0 {
+			room.Contents = make(map[string][]string)
+			for container, contents := range r.Contents {
+				room.Contents[container] = append([]string{}, contents...)
+			}
+		}
+		for _, flag := range r.Flags {
+			room.Flags[flag] = true
+		}
+		for _, obj := range r.Objects {
+			room.Objects[obj.Name] = newObject(obj)
+			room.objectOrder = append(room.objectOrder, obj.Name)
+		}
+		built[r.Name] = room
+	}
+	for _, npc := range w.NPCs {
+		if room, ok := built[npc.Room]; ok {
+			room.npcs = append(room.npcs, npc)
+		}
+	}
+	return built, built[w.Start]
+}
+
+// newObject собирает объект и его действия из описания
+func newObject(def ObjectDef) GameObject {
+	obj := GameObject{Name: def.Name, Exit: def.Exit, State: def.State, def: def}
+	for _, a := range def.Actions {
+		action := Action{
+			action:          actionTypes[a.Type],
+			item:            a.Item,
+			from:            a.From,
+			when:            a.If,
+			effects:         a.Effects,
+			afterCommentary: a.Commentary,
+			failure:         a.Fail,
+		}
+		// переход - это перемещение игрока после остальных эффектов
+		if a.To != "" {
+			action.effects = append(append([]Effect{}, a.Effects...), Effect{Move: a.To})
+		}
+		// новое состояние объекта - тоже эффект, после остальных
+		if a.State != "" {
+			action.effects = append(append([]Effect{}, action.effects...), Effect{Object: def.Name, State: a.State})
+		}
+		if action.action == ActionGo && def.State != "" && len(action.from) == 0 {
+			action.from = []string{StateOpen}
+		}
+		obj.Actions = append(obj.Actions, action)
+	}
+	return obj
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseWorld_Default(t *testing.T) {
+	w, err := ParseWorld(defaultWorld)
+	if err != nil {
+		t.Fatalf("default world is invalid: %v", err)
+	}
+	if w.Start != "кухня" || len(w.Rooms) != 4 {
+		t.Fatalf("unexpected world: start=%q rooms=%d", w.Start, len(w.Rooms))
+	}
+}
+
+func TestParseWorld_ReportsAllErrors(t *testing.T) {
+	data := `{
+		"start": "чердак",
+		"rooms": [
+			{"name": "кухня", "items": ["чай"], "objects": [
+				{"name": "коридор", "actions": [{"type": "go", "to": "коридор"}]},
+				{"name": "плита", "actions": [
+					{"type": "use", "item": "спички"},
+					{"type": "jump"},
+					{"type": "use", "item": "чай", "if": {"any": [{"flag": "газ"}, {"has": "зажигалка"}]}, "effects": [{"move": "подвал"}]}
+				]}
+			]},
+			{"name": "кухня"}
+		]
+	}`
+	_, err := ParseWorld([]byte(data))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	werrs, ok := err.(WorldErrors)
+	if !ok {
+		t.Fatalf("expected WorldErrors, got %T: %v", err, err)
+	}
+	if len(werrs) != 8 {
+		t.Fatalf("expected 8 errors, got %d:\n%v", len(werrs), err)
+	}
+	for _, want := range []string{
+		`комната "кухня" описана несколько раз`,
+		`стартовая комната "чердак" не существует`,
+		`переход в неизвестную комнату "коридор"`,
+		`требуется неизвестный предмет "спички"`,
+		`неизвестное действие "jump"`,
+		`флаг "газ" нигде не устанавливается`,
+		`неизвестный предмет "зажигалка"`,
+		`эффект 1: неизвестная комната "подвал"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q not reported:\n%v", want, err)
+		}
+	}
+}
+
+func TestParseWorld_BadJSON(t *testing.T) {
+	if _, err := ParseWorld([]byte("{")); err == nil {
+		t.Fatal("expected error for malformed JSON")
+	}
+}
+
+func TestLoadWorld_CustomQuest(t *testing.T) {
+	data := `{
+		"start": "подвал",
+		"rooms": [
+			{"name": "подвал", "description": "темно", "items": ["фонарь"], "places": {"фонарь": "в углу"}, "objects": [
+				{"name": "люк", "actions": [{"type": "go", "to": "двор", "commentary": "ты во дворе"}]}
+			]},
+			{"name": "двор", "description": "светло", "items": []}
+		]
+	}`
+	path := filepath.Join(t.TempDir(), "quest.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := LoadWorld(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	original := world
+	defer func() { world = original }()
+	world = w
+
+	initGame()
+	if got := handleCommand("осмотреться"); got != "темно, в углу: фонарь. можно пройти - люк" {
+		t.Errorf("unexpected look: %q", got)
+	}
+	if got := handleCommand("идти люк"); got != "ты во дворе" {
+		t.Errorf("unexpected go: %q", got)
+	}
+	if got := handleCommand("осмотреться"); got != "светло" {
+		t.Errorf("unexpected look after go: %q", got)
+	}
+}